@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+)
+
+var functionsCmd = &cobra.Command{
+	Use:   "functions",
+	Short: "List SQL functions provided by qo",
+	Long:  "List SQL functions that qo adds on top of SQLite's built-in functions, including user-defined ones.",
+	Args:  cobra.NoArgs,
+	RunE:  listFunctions,
+}
+
+func init() {
+	rootCmd.AddCommand(functionsCmd)
+}
+
+func listFunctions(cmd *cobra.Command, _ []string) error {
+	if err := registerUserFunctions(); err != nil {
+		return err
+	}
+	defer func() { _ = db.CloseDefinitions() }()
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "FUNCTION\tKIND\tDESCRIPTION")
+	for _, fn := range db.Functions() {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", fn.Signature(), fn.Kind(), fn.Description)
+	}
+	return w.Flush()
+}
+
+// registerUserFunctions registers the functions defined in the --functions file,
+// or in the default functions file if it exists.
+func registerUserFunctions() error {
+	path := functionsPath
+	if path == "" {
+		path = defaultFunctionsPath()
+		if path == "" {
+			return nil
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+	}
+	return db.LoadDefinitions(path)
+}
+
+// defaultFunctionsPath returns the path of the functions file in the user's config directory.
+func defaultFunctionsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "qo", "functions.sql")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+var (
+	profiling     bool
+	profileTables []string
+	profileBins   int
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile [files...]",
+	Short: "Summarize the values of each column of loaded tables",
+	Long: "Load the inputs like qo does and print a profile of each column: row and NULL counts, " +
+		"distinct values, min/max, the most frequent values and, for numeric columns, the mean and a histogram.",
+	Example: strings.Join([]string{
+		`  qo profile -o table sales.csv -i csv`,
+		`  qo profile --table users users.json orders.json`,
+	}, "\n"),
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if profileBins < 1 {
+			return fmt.Errorf("invalid bins: %d (must be at least 1)", profileBins)
+		}
+		profiling = true
+		return runQuery(cmd, args)
+	},
+}
+
+func init() {
+	profileCmd.Flags().StringArrayVarP(&profileTables, "table", "t", nil, "Profile only this table (repeatable, default: all loaded tables)")
+	profileCmd.Flags().IntVar(&profileBins, "bins", 10, "Number of histogram bins for numeric columns")
+	rootCmd.AddCommand(profileCmd)
+}
+
+// printProfile prints a profile of each column of the given tables, or of
+// the tables selected with --table.
+func printProfile(database *db.DB, tableNames []string) error {
+	if len(profileTables) > 0 {
+		tableNames = profileTables
+	}
+	structured := output.Format(outputFormat) == output.FormatJSON || output.Format(outputFormat) == output.FormatJSONL
+
+	columns := []string{"table", "column", "type", "count", "nulls", "null_pct", "distinct", "min", "max", "mean", "top_values", "histogram"}
+	var data [][]any
+	for _, name := range tableNames {
+		schema, table, ok := strings.Cut(name, ".")
+		if !ok {
+			schema, table = "main", name
+		}
+		profiles, err := database.ProfileTable(schema, table, profileBins)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range profiles {
+			var nullPct float64
+			if p.Count > 0 {
+				nullPct = math.Round(float64(p.Nulls)/float64(p.Count)*10000) / 100
+			}
+			var mean any
+			if p.Mean != nil {
+				mean = *p.Mean
+			}
+
+			row := []any{name, p.Name, p.Type.Name(), p.Count, p.Nulls, nullPct, p.Distinct,
+				output.NormalizeTypedValue(p.Min, p.Type), output.NormalizeTypedValue(p.Max, p.Type), mean}
+			if structured {
+				row = append(row, topValuesJSON(p.TopValues, p.Type), histogramJSON(p.Histogram))
+			} else {
+				row = append(row, topValuesText(p.TopValues, p.Type), sparkline(p.Histogram))
+			}
+			data = append(data, row)
+		}
+	}
+
+	return withOutput(func(out io.Writer) error {
+		return output.NewPrinter(printerOptions(out)).PrintData(columns, data)
+	})
+}
+
+func topValuesJSON(values []db.ValueCount, typ parser.DataType) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = map[string]any{"value": output.NormalizeTypedValue(v.Value, typ), "count": v.Count}
+	}
+	return result
+}
+
+func histogramJSON(hist []db.Bin) any {
+	if hist == nil {
+		return nil
+	}
+	result := make([]any, len(hist))
+	for i, b := range hist {
+		result[i] = map[string]any{"low": b.Low, "high": b.High, "count": b.Count}
+	}
+	return result
+}
+
+// topValuesText formats the most frequent values as "value (count), ...".
+func topValuesText(values []db.ValueCount, typ parser.DataType) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%s (%d)", output.FormatValueRaw(output.NormalizeTypedValue(v.Value, typ)), v.Count)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sparkline draws a histogram as a row of block characters.
+func sparkline(hist []db.Bin) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+
+	var peak int64
+	for _, b := range hist {
+		peak = max(peak, b.Count)
+	}
+	if peak == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, b := range hist {
+		if b.Count == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		level := int(b.Count * int64(len(levels)-1) / peak)
+		sb.WriteRune(levels[level])
+	}
+	return sb.String()
+}
@@ -2,9 +2,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,16 +18,44 @@ import (
 	"github.com/kiki-ki/go-qo/internal/db"
 	"github.com/kiki-ki/go-qo/internal/input"
 	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+	"github.com/kiki-ki/go-qo/internal/stats"
 	"github.com/kiki-ki/go-qo/internal/ui"
 )
 
 var version = "dev"
 
 var (
-	outputFormat string
-	inputFormat  string
-	queryFlag    string
-	noHeader     bool
+	outputFormat   string
+	duplicates     string
+	htmlFull       bool
+	xmlRoot        string
+	xmlRow         string
+	sqlDialect     string
+	sqlTable       string
+	outputFile     string
+	compression    string
+	inputFormat    string
+	queries        []string
+	scriptPath     string
+	printAll       bool
+	separator      string
+	paramSpecs     []string
+	paramsJSON     string
+	timeout        time.Duration
+	readOnly       bool
+	schemaOnly     bool
+	explain        bool
+	statsFormat    string
+	noHeader       bool
+	jobs           int
+	dbPath         string
+	useCache       bool
+	attachWritable bool
+	saveDBPath     string
+	indexSpecs     []string
+	autoIndex      bool
+	functionsPath  string
 )
 
 const stdinTableName = "tmp"
@@ -36,6 +70,9 @@ var rootCmd = &cobra.Command{
 		"  cat data.json | qo                                  # Pipe to TUI, output to stdout",
 		`  qo -q "SELECT * FROM data" data.json                # Direct query mode`,
 		`  qo -i csv -o json data.csv -q "SELECT * FROM data"  # CSV to JSON`,
+		`  qo -f report.sql data.json                          # Run a SQL script`,
+		`  qo --db cache.sqlite --cache big.json               # Reuse loaded tables across runs`,
+		`  qo data.json ref.sqlite                             # Attach ref.sqlite as schema "ref"`,
 	}, "\n"),
 	Args: cobra.ArbitraryArgs,
 	RunE: runQuery,
@@ -43,16 +80,57 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.Flags().StringVarP(&inputFormat, "input", "i", "json", "Input format: json, csv, tsv")
-	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "json", "Output format: json, jsonl, csv, tsv, table")
-	rootCmd.Flags().StringVarP(&queryFlag, "query", "q", "", "SQL query to execute (if omitted, interactive mode)")
+	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "json", "Output format: json, jsonl, csv, tsv, table, markdown, html, yaml, xml, toml, sql, parquet, arrow")
+	rootCmd.Flags().BoolVar(&htmlFull, "html-full", false, "Wrap HTML output in a complete document")
+	rootCmd.Flags().StringVar(&xmlRoot, "xml-root", output.DefaultXMLRoot, "Name of the root element of XML output")
+	rootCmd.Flags().StringVar(&xmlRow, "xml-row", output.DefaultXMLRow, "Name of the element of each row in XML output")
+	rootCmd.Flags().StringVar(&sqlDialect, "sql-dialect", string(output.DialectSQLite), "Dialect of SQL output: sqlite, postgres or mysql")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write results to this file instead of stdout (required for parquet and arrow)")
+	rootCmd.Flags().StringVar(&compression, "compression", "", "Compression of parquet (snappy, none, gzip, brotli, lz4, zstd) or arrow (none, lz4, zstd) output")
+	rootCmd.Flags().StringVar(&sqlTable, "sql-table", "", "Table SQL output inserts into (default: the loaded table if there is one, else result)")
+	rootCmd.Flags().StringVar(&duplicates, "duplicate-columns", string(output.DuplicateSuffix), "Naming of duplicate column names in JSON output: suffix (id, id_1), last or error")
+	rootCmd.Flags().StringArrayVarP(&queries, "query", "q", nil, "SQL to execute, repeatable and run in order (if omitted, interactive mode)")
+	rootCmd.Flags().StringVarP(&scriptPath, "file", "f", "", "SQL script to execute before any -q statements")
+	rootCmd.Flags().BoolVar(&printAll, "print-all", false, "Print the result of every statement that returns rows, not just the last")
+	rootCmd.Flags().StringVar(&separator, "separator", "", "Line printed between results with --print-all (default: empty line)")
+	rootCmd.Flags().StringArrayVar(&paramSpecs, "param", nil, "Bind a query parameter: name=value or name:type=value (repeatable)")
+	rootCmd.Flags().StringVar(&paramsJSON, "params-json", "", "JSON file of query parameters (object of named or array of positional values)")
+	rootCmd.Flags().BoolVar(&readOnly, "read-only", false, "Refuse statements that modify the database")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "Abort queries running longer than this, e.g. 30s (default: no limit)")
 	rootCmd.Flags().BoolVar(&noHeader, "no-header", false, "Treat first row as data, not header (CSV/TSV only)")
+	rootCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of input files to parse concurrently")
+	rootCmd.Flags().StringVar(&dbPath, "db", "", "SQLite database file to load data into (default: in-memory)")
+	rootCmd.Flags().BoolVar(&useCache, "cache", false, "Skip reloading files unchanged since the last load (requires --db)")
+	rootCmd.Flags().BoolVar(&attachWritable, "attach-writable", false, "Attach .db/.sqlite/.sqlite3 arguments read-write instead of read-only")
+	rootCmd.Flags().StringVar(&saveDBPath, "save-db", "", "Save loaded tables and views to a SQLite database file")
+	rootCmd.Flags().StringArrayVar(&indexSpecs, "index", nil, "Create an index on a loaded column (table.column, repeatable)")
+	rootCmd.Flags().BoolVar(&autoIndex, "auto-index", false, "Index columns used in JOIN/WHERE of the query")
+	rootCmd.Flags().BoolVar(&explain, "explain", false, "Print the query plan, load time, query time and row count instead of the result")
+	rootCmd.Flags().StringVar(&statsFormat, "stats", "", "Print timings, bytes read, rows loaded and peak memory to stderr (text or json)")
+	rootCmd.Flags().Lookup("stats").NoOptDefVal = "text"
+	rootCmd.Flags().BoolVar(&schemaOnly, "schema-only", false, "Print the columns of the loaded tables instead of querying them")
+	rootCmd.PersistentFlags().StringVar(&functionsPath, "functions", "", "File of user-defined SQL functions (default: qo/functions.sql in the user config directory, if present)")
+
+	// Subcommands that load inputs share the loading flags
+	for _, cmd := range []*cobra.Command{schemaCmd, profileCmd} {
+		for _, name := range loadFlags {
+			cmd.Flags().AddFlag(rootCmd.Flags().Lookup(name))
+		}
+	}
 }
 
+// loadFlags are the flags that control how inputs are loaded and printed.
+var loadFlags = []string{"input", "output", "output-file", "compression", "html-full", "xml-root", "xml-row", "no-header", "jobs", "db", "cache", "attach-writable"}
+
 // runConfig holds the parsed configuration for a query run.
 type runConfig struct {
-	query      string
+	statements []string // SQL from -f and -q; empty for interactive mode
+	args       []any    // query parameters from --param and --params-json
 	filePaths  []string
+	dbPaths    []string // SQLite database files to attach
 	tableNames []string
+	loadTime   time.Duration // time taken to load inputs and create indexes
+	stats      *stats.Stats  // nil unless --stats is set
 }
 
 func runQuery(cmd *cobra.Command, args []string) error {
@@ -63,18 +141,32 @@ func runQuery(cmd *cobra.Command, args []string) error {
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	if err := validateFormats(); err != nil {
+	if err := validateFlags(); err != nil {
 		return err
 	}
+	if err := registerUserFunctions(); err != nil {
+		return err
+	}
+	defer func() { _ = db.CloseDefinitions() }()
+
+	var runStats *stats.Stats
+	if statsFormat != "" {
+		runStats = stats.New()
+		defer printStats(runStats)
+	}
 
-	database, err := db.New()
+	database, err := db.Open(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	defer func() { _ = database.Close() }()
+	database.SetStats(runStats)
 
 	loader := input.NewLoader(database, input.Format(inputFormat), &input.LoaderOptions{
 		NoHeader: noHeader,
+		Jobs:     jobs,
+		Cache:    useCache,
+		Stats:    runStats,
 	})
 
 	hasStdinData, err := input.HasStdinData()
@@ -82,33 +174,121 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	cfg := &runConfig{
-		query:     queryFlag,
-		filePaths: args,
+	cfg := &runConfig{stats: runStats}
+	if cfg.statements, err = readStatements(); err != nil {
+		return err
+	}
+	if cfg.args, err = readParams(); err != nil {
+		return err
+	}
+	for _, path := range args {
+		if db.IsDatabaseFile(path) {
+			cfg.dbPaths = append(cfg.dbPaths, path)
+		} else {
+			cfg.filePaths = append(cfg.filePaths, path)
+		}
 	}
 
+	start := time.Now()
 	if err := loadData(loader, cfg, hasStdinData); err != nil {
 		return err
 	}
+	if err := attachDatabases(database, cfg); err != nil {
+		return err
+	}
+	if err := createIndexes(database, cfg); err != nil {
+		return err
+	}
+	cfg.loadTime = time.Since(start)
+	cfg.stats.SetLoad(cfg.loadTime)
+	switch {
+	case schemaOnly:
+		return printSchema(database, cfg.tableNames)
+	case profiling:
+		return printProfile(database, cfg.tableNames)
+	}
+
+	if err := execute(database, cfg); err != nil {
+		return err
+	}
 
-	return execute(database, cfg)
+	if saveDBPath != "" {
+		return database.SaveTo(saveDBPath)
+	}
+	return nil
 }
 
-// validateFormats checks if input/output formats are valid.
-func validateFormats() error {
+// validateOutputFile checks the flags of binary output formats, which must be
+// written to a file.
+func validateOutputFile() error {
+	format := output.Format(outputFormat)
+	if output.IsBinaryFormat(format) {
+		if outputFile == "" {
+			return fmt.Errorf("-o %s writes binary data; set the destination with --output-file", format)
+		}
+		if printAll {
+			return fmt.Errorf("--print-all cannot be used with -o %s", format)
+		}
+	}
+	if compression == "" {
+		return nil
+	}
+	if output.Compressions(format) == nil {
+		return fmt.Errorf("--compression requires -o parquet or -o arrow")
+	}
+	if !output.IsValidCompression(format, compression) {
+		return fmt.Errorf("unsupported %s compression: %s (supported: %v)", format, compression, output.Compressions(format))
+	}
+	return nil
+}
+
+// validateFlags checks if flag values such as input/output formats are valid.
+func validateFlags() error {
 	if !input.IsValidFormat(inputFormat) {
 		return fmt.Errorf("unsupported input format: %s (supported: %v)", inputFormat, input.Formats())
 	}
 	if !output.IsValidFormat(outputFormat) {
 		return fmt.Errorf("unsupported output format: %s (supported: %v)", outputFormat, output.Formats())
 	}
+	if !output.IsValidDuplicatePolicy(duplicates) {
+		return fmt.Errorf("unsupported duplicate column policy: %s (supported: %v)", duplicates, output.DuplicatePolicies())
+	}
+	if jobs < 1 {
+		return fmt.Errorf("invalid jobs: %d (must be at least 1)", jobs)
+	}
+	if htmlFull && outputFormat != string(output.FormatHTML) {
+		return fmt.Errorf("--html-full requires --output html")
+	}
+	for _, name := range []string{xmlRoot, xmlRow} {
+		if !output.IsValidXMLName(name) {
+			return fmt.Errorf("invalid XML element name: %q", name)
+		}
+	}
+	if !output.IsValidSQLDialect(sqlDialect) {
+		return fmt.Errorf("unsupported SQL dialect: %s (supported: %v)", sqlDialect, output.SQLDialects())
+	}
+	if err := validateOutputFile(); err != nil {
+		return err
+	}
+	if useCache && dbPath == "" {
+		return fmt.Errorf("--cache requires --db")
+	}
+	if timeout < 0 {
+		return fmt.Errorf("invalid timeout: %s (must not be negative)", timeout)
+	}
+	if statsFormat != "" && statsFormat != "text" && statsFormat != "json" {
+		return fmt.Errorf("unsupported stats format: %s (supported: text, json)", statsFormat)
+	}
+	if schemaOnly && (scriptPath != "" || len(queries) > 0) {
+		return fmt.Errorf("--schema-only cannot be used with -q or -f")
+	}
 	return nil
 }
 
 // loadData loads data from stdin and/or files into the database.
 // Returns the list of loaded table names.
 func loadData(loader *input.Loader, cfg *runConfig, hasStdinData bool) error {
-	if !hasStdinData && len(cfg.filePaths) == 0 {
+	if !hasStdinData && len(cfg.filePaths) == 0 && len(cfg.dbPaths) == 0 {
 		return fmt.Errorf("no input data: provide files as arguments or pipe data via stdin")
 	}
 
@@ -134,25 +314,246 @@ func loadData(loader *input.Loader, cfg *runConfig, hasStdinData bool) error {
 	return nil
 }
 
+// attachDatabases attaches SQLite database files under schemas named after the files.
+// Their tables are added to the table names as "schema.table".
+func attachDatabases(database *db.DB, cfg *runConfig) error {
+	for _, path := range cfg.dbPaths {
+		schema := db.TableNameFromPath(path)
+		if err := database.Attach(path, schema, attachWritable); err != nil {
+			return err
+		}
+
+		tables, err := database.Tables(schema)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			cfg.tableNames = append(cfg.tableNames, schema+"."+table)
+		}
+	}
+	return nil
+}
+
+// readParams returns the query parameters from --params-json, overridden by --param.
+func readParams() ([]any, error) {
+	params := cli.NewParams()
+	if paramsJSON != "" {
+		if err := params.LoadJSON(paramsJSON); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range paramSpecs {
+		if err := params.Parse(spec); err != nil {
+			return nil, err
+		}
+	}
+	return params.Args()
+}
+
+// createIndexes creates the indexes requested with --index, and with
+// --auto-index those needed by the statements given via -f and -q.
+func createIndexes(database *db.DB, cfg *runConfig) error {
+	for _, spec := range indexSpecs {
+		idx, err := db.ParseIndex(spec)
+		if err != nil {
+			return err
+		}
+		if err := database.CreateIndex(idx); err != nil {
+			return err
+		}
+	}
+
+	if autoIndex {
+		for _, stmt := range cfg.statements {
+			if _, err := database.AutoIndex(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readStatements returns the SQL statements of the -f script followed by those of each -q.
+func readStatements() ([]string, error) {
+	var statements []string
+	if scriptPath != "" {
+		script, err := os.ReadFile(scriptPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read script: %w", err)
+		}
+		statements = append(statements, sqllex.Split(string(script))...)
+	}
+	for _, query := range queries {
+		statements = append(statements, sqllex.Split(query)...)
+	}
+
+	if len(statements) == 0 && (scriptPath != "" || len(queries) > 0) {
+		return nil, fmt.Errorf("no SQL statements to execute")
+	}
+	return statements, nil
+}
+
 // execute runs either UI or CLI mode based on configuration.
-// UI mode is used when query is empty, CLI mode when query is provided via -q flag.
+// UI mode is used when there are no statements, CLI mode when they are provided via -f or -q.
 func execute(database *db.DB, cfg *runConfig) error {
-	if cfg.query == "" {
-		result, err := ui.Run(database.DB, cfg.tableNames)
+	if len(cfg.statements) == 0 {
+		// Ctrl+S writes only to a file named with --save-db, never to a default path
+		opts := &ui.Options{
+			SavePath: saveDBPath,
+			Save:     database.SaveTo,
+			Args:     cfg.args,
+			Timeout:  timeout,
+			ReadOnly: readOnly,
+			Prepare: func(ctx context.Context, query string) (string, error) {
+				// Queries are indexed while being typed, so failures are not reported;
+				// read-only mode leaves the database untouched
+				if autoIndex && !readOnly {
+					_, _ = database.AutoIndexContext(ctx, query)
+				}
+				return database.RewriteQuery(query)
+			},
+		}
+		result, err := ui.Run(database.DB, cfg.tableNames, opts)
 		if err != nil {
 			return err
 		}
 		if result == nil || result.Query == "" {
 			return nil
 		}
-		cfg.query = result.Query
+		cfg.statements = []string{result.Query}
 	}
-	return cli.Run(database.DB, cfg.query, &cli.Options{
-		Format: output.Format(outputFormat),
-		Output: os.Stdout,
+
+	statements := make([]string, len(cfg.statements))
+	for i, stmt := range cfg.statements {
+		rewritten, err := database.RewriteQuery(stmt)
+		if err != nil {
+			return err
+		}
+		statements[i] = rewritten
+	}
+
+	if readOnly {
+		for i, stmt := range statements {
+			if err := db.CheckReadOnly(stmt); err != nil {
+				return fmt.Errorf("statement %d: %w (writes are disabled by --read-only)", i+1, err)
+			}
+		}
+		if err := database.SetReadOnly(true); err != nil {
+			return err
+		}
+		// Saving with --save-db writes the database
+		defer func() { _ = database.SetReadOnly(false) }()
+	}
+
+	// Ctrl+C interrupts the running statement instead of killing the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return withOutput(func(out io.Writer) error {
+		return runStatements(ctx, database, statements, cfg, out)
 	})
 }
 
+// runStatements runs statements with CLI output to out.
+func runStatements(ctx context.Context, database *db.DB, statements []string, cfg *runConfig, out io.Writer) error {
+	opts := &cli.Options{
+		Format:      output.Format(outputFormat),
+		Output:      out,
+		Args:        cfg.args,
+		Duplicates:  output.DuplicatePolicy(duplicates),
+		Types:       database.ResultTypes,
+		HTMLFull:    htmlFull,
+		XMLRoot:     xmlRoot,
+		XMLRow:      xmlRow,
+		SQLDialect:  output.SQLDialect(sqlDialect),
+		SQLTable:    sqlTargetTable(cfg.tableNames),
+		Compression: compression,
+		PrintAll:    printAll,
+		Separator:   separator,
+		Stats:       cfg.stats,
+	}
+	var err error
+	if explain {
+		_, _ = fmt.Fprintf(out, "Load time: %s\n\n", cfg.loadTime.Round(time.Microsecond))
+		err = cli.ExplainScriptContext(ctx, database.DB, statements, opts)
+	} else {
+		err = cli.RunScriptContext(ctx, database.DB, statements, opts)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("query timed out after %s", timeout)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("query canceled")
+	case readOnly && db.IsReadOnlyError(err):
+		return fmt.Errorf("%w (writes are disabled by --read-only)", err)
+	}
+	return err
+}
+
+// withOutput calls write with the destination of results, the --output-file
+// or stdout, and closes the file afterwards. The file is removed if writing fails.
+func withOutput(write func(out io.Writer) error) error {
+	if outputFile == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write %s: %w", outputFile, cerr)
+	}
+	if err != nil {
+		// A partial file, e.g. Parquet without its footer, is of no use
+		_ = os.Remove(outputFile)
+	}
+	return err
+}
+
+// printerOptions returns the output options set by flags, for results
+// printed by subcommands.
+func printerOptions(out io.Writer) *output.Options {
+	return &output.Options{
+		Format:      output.Format(outputFormat),
+		Output:      out,
+		HTMLFull:    htmlFull,
+		XMLRoot:     xmlRoot,
+		XMLRow:      xmlRow,
+		SQLDialect:  output.SQLDialect(sqlDialect),
+		SQLTable:    sqlTable,
+		Compression: compression,
+	}
+}
+
+// sqlTargetTable returns the table SQL output inserts into: --sql-table if
+// given, else the only table loaded, so that converting a file keeps its name.
+func sqlTargetTable(tableNames []string) string {
+	if sqlTable != "" || len(tableNames) != 1 {
+		return sqlTable
+	}
+	name := tableNames[0]
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:] // table of an attached database
+	}
+	return name
+}
+
+// printStats writes the collected statistics to stderr, keeping stdout for results.
+func printStats(s *stats.Stats) {
+	report := s.Report()
+	if statsFormat == "json" {
+		_ = report.WriteJSON(os.Stderr)
+		return
+	}
+	_ = report.WriteText(os.Stderr)
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/output"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:     "schema [files...]",
+	Aliases: []string{"describe"},
+	Short:   "Describe the columns of loaded tables",
+	Long: "Load the inputs like qo does and print each table's columns with their inferred type, " +
+		"whether they hold NULLs, the number of distinct values and an example value.",
+	Example: strings.Join([]string{
+		`  qo schema users.json orders.csv`,
+		`  cat data.json | qo describe -o json`,
+	}, "\n"),
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schemaOnly = true
+		return runQuery(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// printSchema prints the columns of the given tables, which are qualified
+// with their schema if they belong to an attached database.
+func printSchema(database *db.DB, tableNames []string) error {
+	columns := []string{"table", "column", "type", "nullable", "distinct", "example"}
+	var data [][]any
+
+	for _, name := range tableNames {
+		schema, table, ok := strings.Cut(name, ".")
+		if !ok {
+			schema, table = "main", name
+		}
+		infos, err := database.DescribeTable(schema, table)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			data = append(data, []any{
+				name, info.Name, info.Type.Name(), info.Nullable, info.Distinct, output.NormalizeTypedValue(info.Example, info.Type),
+			})
+		}
+	}
+
+	return withOutput(func(out io.Writer) error {
+		return output.NewPrinter(printerOptions(out)).PrintData(columns, data)
+	})
+}
@@ -1,17 +1,44 @@
 package cli
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+	"github.com/kiki-ki/go-qo/internal/stats"
 )
 
 // Options configures CLI execution.
 type Options struct {
-	Format output.Format
-	Output io.Writer
+	Format      output.Format
+	Output      io.Writer
+	Args        []any                  // arguments bound to placeholders, see Params
+	Duplicates  output.DuplicatePolicy // naming of duplicate columns in JSON output
+	HTMLFull    bool                   // wrap HTML output in a complete document
+	XMLRoot     string                 // name of the root element of XML output
+	XMLRow      string                 // name of the element of each row in XML output
+	SQLDialect  output.SQLDialect      // dialect of SQL output
+	SQLTable    string                 // table SQL output inserts into
+	Compression string                 // codec of Parquet and Arrow output
+
+	// Types returns the source types of the result columns of a query, e.g.
+	// db.DB.ResultTypes, so booleans and JSON values are output as loaded.
+	// Without it, text that holds a JSON object or array is output as JSON.
+	Types func(query string, columns []*sql.ColumnType) []parser.DataType
+
+	// PrintAll prints the result of every statement of a script that returns rows,
+	// instead of only the last one.
+	PrintAll  bool
+	Separator string // line printed between results with PrintAll (default: empty line)
+
+	Stats *stats.Stats // records the time spent running statements; nil to disable
 }
 
 // DefaultOptions returns default CLI options.
@@ -24,20 +51,166 @@ func DefaultOptions() *Options {
 
 // Run executes a SQL query and prints results.
 func Run(db *sql.DB, query string, opts *Options) error {
+	return RunContext(context.Background(), db, query, opts)
+}
+
+// RunContext is like Run but interrupts the query when ctx is done.
+func RunContext(ctx context.Context, db *sql.DB, query string, opts *Options) error {
 	if opts == nil {
 		opts = DefaultOptions()
 	}
+	defer recordQuery(opts.Stats, time.Now())
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query, opts.Args...)
 	if err != nil {
-		return err
+		return contextError(ctx, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return contextError(ctx, newPrinter(opts, query).PrintRows(rows))
+}
+
+// RunScript executes SQL statements in order and prints the result of the last
+// statement that returns rows, or with PrintAll the result of each of them.
+func RunScript(db *sql.DB, statements []string, opts *Options) error {
+	return RunScriptContext(context.Background(), db, statements, opts)
+}
+
+// RunScriptContext is like RunScript but interrupts the running statement when ctx is done.
+func RunScriptContext(ctx context.Context, db *sql.DB, statements []string, opts *Options) error {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	defer recordQuery(opts.Stats, time.Now())
+
+	final := -1
+	if !opts.PrintAll {
+		for i, stmt := range statements {
+			if returnsRows(stmt) {
+				final = i
+			}
+		}
+	}
+
+	printed := 0
+	for i, stmt := range statements {
+		var err error
+		if opts.PrintAll || i == final {
+			var ok bool
+			ok, err = runStatement(ctx, db, stmt, opts, printed > 0)
+			if ok {
+				printed++
+			}
+		} else {
+			_, err = db.ExecContext(ctx, stmt, opts.Args...)
+		}
+
+		if err = contextError(ctx, err); err != nil {
+			if len(statements) > 1 {
+				return fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// runStatement executes a statement and prints its result, preceded by the
+// separator if sep is set. It reports whether the statement returned rows.
+func runStatement(ctx context.Context, db *sql.DB, stmt string, opts *Options, sep bool) (bool, error) {
+	rows, err := db.QueryContext(ctx, stmt, opts.Args...)
+	if err != nil {
+		return false, err
 	}
 	defer func() { _ = rows.Close() }()
 
-	p := output.NewPrinter(&output.Options{
-		Format: opts.Format,
-		Output: opts.Output,
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+	if len(columns) == 0 {
+		for rows.Next() {
+		}
+		return false, rows.Err()
+	}
+
+	if sep {
+		if _, err := fmt.Fprintln(opts.Output, opts.Separator); err != nil {
+			return false, err
+		}
+	}
+	return true, newPrinter(opts, stmt).PrintRows(rows)
+}
+
+// contextError replaces an error caused by interrupting a query with the
+// context's error, so callers can tell cancellation and timeouts apart.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// recordQuery records the time since start as query time.
+func recordQuery(s *stats.Stats, start time.Time) {
+	s.AddQuery(time.Since(start))
+}
+
+// newPrinter returns a printer for the result of query.
+func newPrinter(opts *Options, query string) *output.Printer {
+	var types output.TypeResolver
+	if opts.Types != nil {
+		types = func(columns []*sql.ColumnType) []parser.DataType {
+			return opts.Types(query, columns)
+		}
+	}
+	return output.NewPrinter(&output.Options{
+		Format:      opts.Format,
+		Output:      opts.Output,
+		Duplicates:  opts.Duplicates,
+		Types:       types,
+		HTMLFull:    opts.HTMLFull,
+		XMLRoot:     opts.XMLRoot,
+		XMLRow:      opts.XMLRow,
+		SQLDialect:  opts.SQLDialect,
+		SQLTable:    opts.SQLTable,
+		Compression: opts.Compression,
 	})
+}
 
-	return p.PrintRows(rows)
+// returnsRows reports whether a statement is a query whose result should be
+// printed: SELECT, VALUES, PRAGMA queries, EXPLAIN, or any statement with RETURNING.
+func returnsRows(stmt string) bool {
+	tokens := sqllex.Tokenize(stmt)
+	if len(tokens) == 0 {
+		return false
+	}
+
+	depth := 0
+	write := false // a top-level INSERT/UPDATE/DELETE/REPLACE, e.g. after WITH
+	for i, tok := range tokens {
+		call := i+1 < len(tokens) && tokens[i+1].Is("(") // e.g. replace(x, 'a', 'b')
+		switch {
+		case tok.Is("("):
+			depth++
+		case tok.Is(")"):
+			depth--
+		case tok.Is("returning"):
+			return true
+		case depth == 0 && !call && (tok.Is("insert") || tok.Is("update") || tok.Is("delete") || tok.Is("replace")):
+			write = true
+		}
+	}
+
+	first := tokens[0]
+	switch {
+	case first.Is("select"), first.Is("values"), first.Is("explain"):
+		return true
+	case first.Is("pragma"):
+		return !sqllex.IsPragmaAssignment(tokens)
+	case first.Is("with"):
+		return !write
+	default:
+		return false
+	}
 }
@@ -2,12 +2,17 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kiki-ki/go-qo/internal/cli"
+	"github.com/kiki-ki/go-qo/internal/db"
 	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/parser"
 	"github.com/kiki-ki/go-qo/internal/testutil"
 )
 
@@ -114,3 +119,211 @@ func TestRun_InvalidQuery(t *testing.T) {
 		t.Error("expected error for invalid query")
 	}
 }
+
+func TestRunScript(t *testing.T) {
+	script := []string{
+		"CREATE TABLE test (id INTEGER, name TEXT)",
+		"INSERT INTO test VALUES (1, 'Alice'), (2, 'Bob')",
+		"SELECT count(*) AS n FROM test",
+		"CREATE TEMP VIEW v AS SELECT name FROM test WHERE id = 1",
+		"UPDATE test SET name = 'Ann' WHERE id = 1",
+		"WITH x AS (SELECT name FROM v) SELECT name FROM x",
+		"DELETE FROM test WHERE id = 2",
+	}
+
+	tests := []struct {
+		name string
+		opts cli.Options
+		want string
+	}{
+		{
+			name: "print final",
+			opts: cli.Options{Format: output.FormatCSV},
+			want: "name\nAnn\n",
+		},
+		{
+			name: "print all",
+			opts: cli.Options{Format: output.FormatCSV, PrintAll: true},
+			want: "n\n2\n\nname\nAnn\n",
+		},
+		{
+			name: "print all with separator",
+			opts: cli.Options{Format: output.FormatCSV, PrintAll: true, Separator: "---"},
+			want: "n\n2\n---\nname\nAnn\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			var buf bytes.Buffer
+			tt.opts.Output = &buf
+
+			if err := cli.RunScript(db, script, &tt.opts); err != nil {
+				t.Fatalf("RunScript failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+
+			var count int
+			if err := db.QueryRow("SELECT count(*) FROM test").Scan(&count); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("expected all statements to run, got %d rows", count)
+			}
+		})
+	}
+}
+
+func TestRunScript_Returning(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	script := []string{
+		"CREATE TABLE test (id INTEGER, name TEXT)",
+		"SELECT 'ignored' AS x",
+		"INSERT INTO test VALUES (1, 'Alice') RETURNING id",
+	}
+
+	var buf bytes.Buffer
+	if err := cli.RunScript(db, script, &cli.Options{Format: output.FormatCSV, Output: &buf}); err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+	if buf.String() != "id\n1\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestRunScript_Pragma(t *testing.T) {
+	tests := []struct {
+		name   string
+		script []string
+		want   string
+	}{
+		{
+			name:   "assignment after query",
+			script: []string{"SELECT 1 AS a", "PRAGMA user_version = 3"},
+			want:   "a\n1\n",
+		},
+		{
+			name:   "call-style assignment after query",
+			script: []string{"SELECT 1 AS a", "PRAGMA main.cache_size(100)"},
+			want:   "a\n1\n",
+		},
+		{
+			name:   "query",
+			script: []string{"PRAGMA user_version = 3", "PRAGMA user_version"},
+			want:   "user_version\n3\n",
+		},
+		{
+			name:   "query with argument",
+			script: []string{"CREATE TABLE t (id INTEGER)", "SELECT 1 AS a", "PRAGMA table_info(t)"},
+			want:   "cid,name,type,notnull,dflt_value,pk\n0,id,INTEGER,0,,0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			var buf bytes.Buffer
+			if err := cli.RunScript(db, tt.script, &cli.Options{Format: output.FormatCSV, Output: &buf}); err != nil {
+				t.Fatalf("RunScript failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunScript_Error(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	script := []string{"CREATE TABLE test (id INTEGER)", "INSERT INTO missing VALUES (1)", "SELECT 1"}
+
+	var buf bytes.Buffer
+	err := cli.RunScript(db, script, &cli.Options{Output: &buf})
+	if err == nil || !strings.Contains(err.Error(), "statement 2:") {
+		t.Errorf("expected error for statement 2, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestRunContext_Interrupted(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     context.Context
+		want    error
+	}{
+		{"timeout", 50 * time.Millisecond, context.Background(), context.DeadlineExceeded},
+		{"canceled", 0, canceled, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var buf bytes.Buffer
+			err := cli.RunContext(ctx, db, query, &cli.Options{Format: output.FormatJSON, Output: &buf})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+
+			// The connection stays usable after an interrupted query
+			buf.Reset()
+			if err := cli.Run(db, "SELECT 1 AS n", &cli.Options{Format: output.FormatJSON, Output: &buf}); err != nil {
+				t.Fatalf("query after interruption failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestRun_RoundTrip(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	input := `{"id":1,"ok":true,"tags":["a",{"b":null}],"note":"[1,2]","score":1.5}
+{"id":2,"ok":false,"tags":{"c":[]},"note":"{}","score":null}
+`
+	data, err := parser.ParseJSONBytes([]byte(input))
+	if err != nil {
+		t.Fatalf("failed to parse input: %v", err)
+	}
+	if err := database.LoadData("t", data); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	opts := &cli.Options{Format: output.FormatJSONL, Output: &buf, Types: database.ResultTypes}
+	if err := cli.Run(database.DB, "SELECT * FROM t", opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("got %q, want %q", buf.String(), input)
+	}
+
+	// Text computed from a column stays text; JSON functions give JSON
+	buf.Reset()
+	if err := cli.Run(database.DB, "SELECT lower(note) AS l, json_array(id) FROM t WHERE id = 1", opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := `{"l":"[1,2]","json_array(id)":[1]}` + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
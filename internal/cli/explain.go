@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// PlanNode is a step of a query plan, as reported by EXPLAIN QUERY PLAN.
+type PlanNode struct {
+	Detail   string
+	Children []*PlanNode
+}
+
+// QueryPlan returns the steps SQLite takes to run a statement.
+func QueryPlan(ctx context.Context, db *sql.DB, stmt string, args ...any) ([]*PlanNode, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var roots []*PlanNode
+	nodes := make(map[int64]*PlanNode)
+	for rows.Next() {
+		var id, parent, notUsed int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err
+		}
+		node := &PlanNode{Detail: detail}
+		nodes[id] = node
+		if p, ok := nodes[parent]; ok {
+			p.Children = append(p.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots, rows.Err()
+}
+
+// WritePlan writes a query plan as an indented tree, like the sqlite3 shell.
+func WritePlan(w io.Writer, plan []*PlanNode) error {
+	var sb strings.Builder
+	sb.WriteString("QUERY PLAN\n")
+	writePlanNodes(&sb, plan, "")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writePlanNodes(sb *strings.Builder, nodes []*PlanNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "|--", "|  "
+		if i == len(nodes)-1 {
+			branch, next = "`--", "   "
+		}
+		sb.WriteString(indent + branch + node.Detail + "\n")
+		writePlanNodes(sb, node.Children, indent+next)
+	}
+}
+
+// ExplainScriptContext prints the query plan of each statement instead of its
+// result. Statements still run in order, so that later statements see the
+// effects of earlier ones, and each plan is followed by the statement's run
+// time and row count.
+func ExplainScriptContext(ctx context.Context, db *sql.DB, statements []string, opts *Options) error {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	defer recordQuery(opts.Stats, time.Now())
+
+	for i, stmt := range statements {
+		if err := explainStatement(ctx, db, stmt, opts, i > 0); err != nil {
+			err = contextError(ctx, err)
+			if len(statements) > 1 {
+				return fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// explainStatement prints the plan of a statement, preceded by an empty line
+// if sep is set, then runs it and prints its run time and row count.
+func explainStatement(ctx context.Context, db *sql.DB, stmt string, opts *Options, sep bool) error {
+	plan, err := QueryPlan(ctx, db, stmt, opts.Args...)
+	if err != nil {
+		return err
+	}
+	if sep {
+		if _, err := fmt.Fprintln(opts.Output); err != nil {
+			return err
+		}
+	}
+	if err := WritePlan(opts.Output, plan); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	count, unit, err := countRows(ctx, db, stmt, opts.Args)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(opts.Output, "Query time: %s, %s: %d\n", time.Since(start).Round(time.Microsecond), unit, count)
+	return err
+}
+
+// countRows runs a statement, discarding its result. It returns the number of
+// rows returned, or for statements without a result the number of rows changed.
+func countRows(ctx context.Context, db *sql.DB, stmt string, args []any) (int64, string, error) {
+	if !returnsRows(stmt) {
+		res, err := db.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, "", err
+		}
+		n, _ := res.RowsAffected()
+		return n, "rows affected", nil
+	}
+
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var n int64
+	for rows.Next() {
+		n++
+	}
+	return n, "rows", rows.Err()
+}
@@ -0,0 +1,88 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/cli"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestWritePlan(t *testing.T) {
+	plan := []*cli.PlanNode{
+		{Detail: "SCAN a"},
+		{Detail: "LIST SUBQUERY 1", Children: []*cli.PlanNode{
+			{Detail: "SCAN u"},
+			{Detail: "CREATE BLOOM FILTER"},
+		}},
+		{Detail: "USE TEMP B-TREE FOR ORDER BY"},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WritePlan(&buf, plan); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	want := "QUERY PLAN\n" +
+		"|--SCAN a\n" +
+		"|--LIST SUBQUERY 1\n" +
+		"|  |--SCAN u\n" +
+		"|  `--CREATE BLOOM FILTER\n" +
+		"`--USE TEMP B-TREE FOR ORDER BY\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestQueryPlan(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	if _, err := db.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"scan", "SELECT * FROM t WHERE name = 'a'", "SCAN t"},
+		{"search", "SELECT * FROM t WHERE id = ?", "SEARCH t USING INTEGER PRIMARY KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := cli.QueryPlan(context.Background(), db, tt.query, 1)
+			if err != nil {
+				t.Fatalf("QueryPlan failed: %v", err)
+			}
+			if len(plan) == 0 || !strings.HasPrefix(plan[0].Detail, tt.want) {
+				t.Errorf("expected plan starting with %q, got %+v", tt.want, plan)
+			}
+		})
+	}
+}
+
+func TestExplainScriptContext(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	script := []string{
+		"CREATE TABLE t (id INTEGER)",
+		"INSERT INTO t VALUES (1), (2), (3)",
+		"SELECT * FROM t WHERE id > 1",
+	}
+
+	var buf bytes.Buffer
+	if err := cli.ExplainScriptContext(context.Background(), db, script, &cli.Options{Output: &buf}); err != nil {
+		t.Fatalf("ExplainScriptContext failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"rows affected: 3", "`--SCAN t", "rows: 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"id"`) {
+		t.Errorf("expected no result rows in output:\n%s", out)
+	}
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Params collects query parameters bound to :name, @name, $name and ? placeholders.
+// Parameters named by a number (1, 2, ...) are positional.
+type Params struct {
+	named      map[string]any
+	positional map[int]any
+}
+
+// NewParams returns an empty parameter set.
+func NewParams() *Params {
+	return &Params{
+		named:      make(map[string]any),
+		positional: make(map[int]any),
+	}
+}
+
+// Set sets a parameter, replacing any earlier value with the same name.
+func (p *Params) Set(name string, value any) error {
+	name = strings.TrimLeft(name, ":@$?")
+	if name == "" {
+		return fmt.Errorf("empty parameter name")
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 1 {
+			return fmt.Errorf("invalid positional parameter %s: must be at least 1", name)
+		}
+		p.positional[n] = value
+		return nil
+	}
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		return fmt.Errorf("invalid parameter name %s: must begin with a letter or be a number", name)
+	}
+	p.named[name] = value
+	return nil
+}
+
+// Parse sets a parameter from a "name=value" or "name:type=value" flag value.
+// Types are text (default), int, float, bool, json and null.
+func (p *Params) Parse(spec string) error {
+	key, raw, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("invalid parameter %q: expected name=value", spec)
+	}
+	name, typ, _ := strings.Cut(key, ":")
+
+	value, err := coerceParam(raw, typ)
+	if err != nil {
+		return fmt.Errorf("invalid parameter %q: %w", spec, err)
+	}
+	return p.Set(name, value)
+}
+
+// LoadJSON sets parameters from a JSON file holding an object of named
+// parameters or an array of positional ones.
+func (p *Params) LoadJSON(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read params file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var params any
+	if err := dec.Decode(&params); err != nil {
+		return fmt.Errorf("failed to parse params file %s: %w", path, err)
+	}
+
+	switch params := params.(type) {
+	case map[string]any:
+		for name, v := range params {
+			if err := p.Set(name, jsonParam(v)); err != nil {
+				return fmt.Errorf("params file %s: %w", path, err)
+			}
+		}
+	case []any:
+		for i, v := range params {
+			p.positional[i+1] = jsonParam(v)
+		}
+	default:
+		return fmt.Errorf("params file %s: expected a JSON object or array", path)
+	}
+	return nil
+}
+
+// Args returns the parameters as query arguments: positional values in
+// order, or named values. SQLite numbers named parameters along with ?
+// placeholders, so a ? after :name is the second parameter and the two kinds
+// cannot be mixed.
+func (p *Params) Args() ([]any, error) {
+	if len(p.positional) > 0 && len(p.named) > 0 {
+		return nil, fmt.Errorf("named and positional parameters cannot be mixed")
+	}
+
+	var args []any
+	for i := 1; i <= len(p.positional); i++ {
+		v, ok := p.positional[i]
+		if !ok {
+			return nil, fmt.Errorf("missing positional parameter %d", i)
+		}
+		args = append(args, v)
+	}
+
+	names := make([]string, 0, len(p.named))
+	for name := range p.named {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		args = append(args, sql.Named(name, p.named[name]))
+	}
+	return args, nil
+}
+
+// coerceParam converts a flag value to the given type.
+func coerceParam(raw, typ string) (any, error) {
+	var (
+		v   any
+		err error
+	)
+	switch strings.ToLower(typ) {
+	case "", "text", "string":
+		return raw, nil
+	case "int", "integer":
+		v, err = strconv.ParseInt(raw, 10, 64)
+	case "float", "real":
+		v, err = strconv.ParseFloat(raw, 64)
+	case "bool", "boolean":
+		v, err = strconv.ParseBool(raw)
+	case "json":
+		if !json.Valid([]byte(raw)) {
+			return nil, fmt.Errorf("malformed JSON")
+		}
+		return raw, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q (supported: text, int, float, bool, json, null)", typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s", raw, typ)
+	}
+	return v, nil
+}
+
+// jsonParam converts a decoded JSON value to a query argument.
+// Objects and arrays are passed as JSON text.
+func jsonParam(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,193 @@
+package cli_test
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/cli"
+	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestParams_Parse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want any
+	}{
+		{"name=O'Brien", sql.Named("name", "O'Brien")},
+		{"q=a=b", sql.Named("q", "a=b")},
+		{"id:int=42", sql.Named("id", int64(42))},
+		{"ratio:float=0.5", sql.Named("ratio", 0.5)},
+		{"ok:bool=true", sql.Named("ok", true)},
+		{"doc:json={\"a\":1}", sql.Named("doc", `{"a":1}`)},
+		{"none:null=", sql.Named("none", nil)},
+		{"$dollar=x", sql.Named("dollar", "x")},
+		{"1:int=7", int64(7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			params := cli.NewParams()
+			if err := params.Parse(tt.spec); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			args, err := params.Args()
+			if err != nil {
+				t.Fatalf("Args failed: %v", err)
+			}
+			if len(args) != 1 || !reflect.DeepEqual(args[0], tt.want) {
+				t.Errorf("got %#v, want %#v", args, tt.want)
+			}
+		})
+	}
+}
+
+func TestParams_ParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"novalue",
+		"=x",
+		"id:int=abc",
+		"ok:bool=maybe",
+		"doc:json={",
+		"x:date=2024",
+		"_x=1",
+		"0=zero",
+	} {
+		if err := cli.NewParams().Parse(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+
+	params := cli.NewParams()
+	if err := params.Parse("2=b"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := params.Args(); err == nil {
+		t.Error("expected error for missing positional parameter 1")
+	}
+
+	// A ? after @a is parameter 2, so positional values would bind to the wrong placeholder
+	mixed := cli.NewParams()
+	for _, spec := range []string{"a=x", "1:int=2"} {
+		if err := mixed.Parse(spec); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+	}
+	if _, err := mixed.Args(); err == nil || !strings.Contains(err.Error(), "cannot be mixed") {
+		t.Errorf("expected error for mixed named and positional parameters, got %v", err)
+	}
+}
+
+func TestParams_LoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	objPath := filepath.Join(dir, "params.json")
+	arrPath := filepath.Join(dir, "positional.json")
+	writeFile(t, objPath, `{"id": 2, "ratio": 1.5, "tags": ["a", "b"], "name": "x", "flag": null}`)
+	writeFile(t, arrPath, `["first", 2]`)
+
+	params := cli.NewParams()
+	if err := params.LoadJSON(objPath); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+	// Flags override file values
+	if err := params.Parse("name=override"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	args, err := params.Args()
+	if err != nil {
+		t.Fatalf("Args failed: %v", err)
+	}
+	want := []any{
+		sql.Named("flag", nil), sql.Named("id", int64(2)), sql.Named("name", "override"),
+		sql.Named("ratio", 1.5), sql.Named("tags", `["a","b"]`),
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("got %#v, want %#v", args, want)
+	}
+
+	positional := cli.NewParams()
+	if err := positional.LoadJSON(arrPath); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+	args, err = positional.Args()
+	if err != nil {
+		t.Fatalf("Args failed: %v", err)
+	}
+	if want := []any{"first", int64(2)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got %#v, want %#v", args, want)
+	}
+
+	writeFile(t, objPath, `"scalar"`)
+	if err := cli.NewParams().LoadJSON(objPath); err == nil {
+		t.Error("expected error for scalar params file")
+	}
+}
+
+func TestRun_Params(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE test (id INTEGER, name TEXT);
+		INSERT INTO test VALUES (1, 'Alice'), (2, 'O''Brien');
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+
+	params := cli.NewParams()
+	for _, spec := range []string{"name=O'Brien", "id:int=1"} {
+		if err := params.Parse(spec); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+	}
+	args, err := params.Args()
+	if err != nil {
+		t.Fatalf("Args failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	statements := []string{
+		"UPDATE test SET name = upper(name) WHERE id = :id",
+		"SELECT id, name FROM test WHERE name = $name OR id = :id ORDER BY id",
+	}
+	err = cli.RunScript(db, statements, &cli.Options{Format: output.FormatCSV, Output: &buf, Args: args})
+	if err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+	if buf.String() != "id,name\n1,ALICE\n2,O'Brien\n" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	positional := cli.NewParams()
+	if err := positional.Parse("1:int=2"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	args, err = positional.Args()
+	if err != nil {
+		t.Fatalf("Args failed: %v", err)
+	}
+	buf.Reset()
+	err = cli.Run(db, "SELECT name FROM test WHERE id = ?", &cli.Options{Format: output.FormatCSV, Output: &buf, Args: args})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if buf.String() != "name\nO'Brien\n" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	if err := cli.Run(db, "SELECT :missing", &cli.Options{Output: &buf}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing parameter error, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
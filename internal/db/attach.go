@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// databaseExtensions lists file extensions recognized as SQLite databases.
+var databaseExtensions = []string{".db", ".sqlite", ".sqlite3"}
+
+// IsDatabaseFile reports whether the path looks like a SQLite database file.
+func IsDatabaseFile(path string) bool {
+	return slices.Contains(databaseExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// Attach attaches the SQLite database file at path under the given schema name.
+// The database is opened read-only unless writable is true. It is never created.
+func (db *DB) Attach(path, schema string, writable bool) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	mode := "ro"
+	if writable {
+		mode = "rw"
+	}
+	uri := &url.URL{Scheme: "file", Path: absPath, RawQuery: "mode=" + mode}
+
+	if _, err := db.Exec(fmt.Sprintf("ATTACH DATABASE ? AS `%s`", schema), uri.String()); err != nil {
+		return fmt.Errorf("failed to attach %s: %w", path, err)
+	}
+	return nil
+}
+
+// Tables returns the names of tables and views in the given schema.
+// Internal tables of SQLite and qo are excluded.
+func (db *DB) Tables(schema string) ([]string, error) {
+	query := fmt.Sprintf("SELECT name FROM `%s`.sqlite_master WHERE type IN ('table', 'view') "+
+		"AND name NOT LIKE 'sqlite_%%' AND name NOT LIKE '\\_qo\\_%%' ESCAPE '\\' ORDER BY name", schema)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables in %s: %w", schema, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
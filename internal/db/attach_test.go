@@ -0,0 +1,117 @@
+package db_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestIsDatabaseFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"ref.db", true},
+		{"path/to/ref.sqlite", true},
+		{"REF.SQLITE3", true},
+		{"data.json", false},
+		{"sqlite", false},
+	}
+
+	for _, tt := range tests {
+		if got := db.IsDatabaseFile(tt.path); got != tt.want {
+			t.Errorf("IsDatabaseFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDB_Attach(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ref.sqlite")
+	src, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	_, err = src.Exec(`
+		CREATE TABLE users (id INTEGER, name TEXT);
+		INSERT INTO users VALUES (1, 'Alice');
+		CREATE VIEW names AS SELECT name FROM users;
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+	if err := src.Close(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	if err := database.Attach(path, "ref", false); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+
+	tables, err := database.Tables("ref")
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if !slices.Equal(tables, []string{"names", "users"}) {
+		t.Errorf("Tables() = %v, want [names users]", tables)
+	}
+
+	var name string
+	if err := database.QueryRow("SELECT name FROM ref.users WHERE id = 1").Scan(&name); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if name != "Alice" {
+		t.Errorf("expected Alice, got %s", name)
+	}
+
+	if _, err := database.Exec("DELETE FROM ref.users"); err == nil {
+		t.Error("expected write to read-only attached database to fail")
+	}
+}
+
+func TestDB_Attach_Writable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ref.db")
+	src, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if _, err := src.Exec("CREATE TABLE users (id INTEGER)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := src.Close(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	if err := database.Attach(path, "ref", true); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	if _, err := database.Exec("INSERT INTO ref.users VALUES (1)"); err != nil {
+		t.Errorf("expected write to writable attached database to succeed: %v", err)
+	}
+}
+
+func TestDB_Attach_MissingFile(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	if err := database.Attach(filepath.Join(t.TempDir(), "missing.db"), "missing", false); err == nil {
+		t.Error("expected error for missing database file")
+	}
+}
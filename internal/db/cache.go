@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+// sourcesTable records where each table was loaded from, so that unchanged
+// sources can be skipped when reusing a file-backed database.
+const sourcesTable = "_qo_sources"
+
+// columnsTable records the types inferred for the columns of each recorded
+// table, which the declared SQLite types lose (e.g. BOOLEAN and JSON).
+const columnsTable = "_qo_columns"
+
+// SourceInfo identifies the state of a source file at the time it was loaded.
+type SourceInfo struct {
+	Path    string // absolute path of the source file
+	Options string // loader format and options used to parse the file
+	Size    int64
+	ModTime int64  // modification time in unix nanoseconds
+	Hash    string // hex-encoded SHA-256 of the file content
+}
+
+// SameFile reports whether both infos refer to the same file parsed the same way.
+func (s *SourceInfo) SameFile(other *SourceInfo) bool {
+	return s.Path == other.Path && s.Options == other.Options && s.Size == other.Size
+}
+
+// LoadedSource returns the source recorded for a table, or nil if the table
+// has no record or no longer exists.
+func (db *DB) LoadedSource(tableName string) (*SourceInfo, error) {
+	if err := db.initSources(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT path, options, size, mod_time, hash FROM %s s
+		WHERE table_name = ? AND EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = s.table_name)`, sourcesTable)
+
+	var info SourceInfo
+	err := db.QueryRow(query, tableName).Scan(&info.Path, &info.Options, &info.Size, &info.ModTime, &info.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source of table %s: %w", tableName, err)
+	}
+	return &info, nil
+}
+
+// RecordSource stores the source a table was loaded from, along with the
+// column types inferred when it was loaded in this session.
+func (db *DB) RecordSource(tableName string, info *SourceInfo) error {
+	if err := db.initSources(); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO %s (table_name, path, options, size, mod_time, hash)
+		VALUES (?, ?, ?, ?, ?, ?)`, sourcesTable)
+	if _, err := db.Exec(query, tableName, info.Path, info.Options, info.Size, info.ModTime, info.Hash); err != nil {
+		return fmt.Errorf("failed to record source of table %s: %w", tableName, err)
+	}
+
+	columns, ok := db.loadedColumns[tableName]
+	if !ok {
+		return nil
+	}
+	if err := db.recordColumns(tableName, columns); err != nil {
+		return fmt.Errorf("failed to record columns of table %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// recordColumns replaces the recorded columns of a table.
+func (db *DB) recordColumns(tableName string, columns []parser.Column) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE table_name = ?", columnsTable), tableName); err != nil {
+		return err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (table_name, position, column_name, type) VALUES (?, ?, ?, ?)", columnsTable)
+	for i, col := range columns {
+		if _, err := tx.Exec(insert, tableName, i, col.Name, col.Type.Name()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RestoreColumns makes the column types recorded for a table available as if
+// it had been loaded in this session, for tables reused from the cache.
+func (db *DB) RestoreColumns(tableName string) error {
+	if err := db.initSources(); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT column_name, type FROM %s WHERE table_name = ? ORDER BY position", columnsTable)
+	rows, err := db.Query(query, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of table %s: %w", tableName, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var columns []parser.Column
+	for rows.Next() {
+		var name, typeName string
+		if err := rows.Scan(&name, &typeName); err != nil {
+			return fmt.Errorf("failed to read columns of table %s: %w", tableName, err)
+		}
+		columns = append(columns, parser.Column{Name: name, Type: typeFromName(typeName)})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of table %s: %w", tableName, err)
+	}
+
+	// Tables cached before column types were recorded fall back to declared types
+	if len(columns) > 0 {
+		db.setLoadedColumns(tableName, columns)
+	}
+	return nil
+}
+
+// typeFromName returns the type whose Name is name, or TypeText if there is none.
+func typeFromName(name string) parser.DataType {
+	for _, typ := range []parser.DataType{parser.TypeInteger, parser.TypeReal, parser.TypeBoolean, parser.TypeJSON, parser.TypeNull} {
+		if typ.Name() == name {
+			return typ
+		}
+	}
+	return parser.TypeText
+}
+
+// initSources creates the sources and columns tables if they do not exist.
+func (db *DB) initSources() error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		table_name TEXT PRIMARY KEY,
+		path TEXT NOT NULL,
+		options TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL,
+		hash TEXT NOT NULL
+	)`, sourcesTable)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", sourcesTable, err)
+	}
+
+	query = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		table_name TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		column_name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		PRIMARY KEY (table_name, position)
+	)`, columnsTable)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", columnsTable, err)
+	}
+	return nil
+}
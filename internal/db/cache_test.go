@@ -0,0 +1,97 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestOpen_Persistent(t *testing.T) {
+	memory, err := db.Open("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.CloseDB(t, memory)
+	if memory.Persistent() {
+		t.Error("expected empty path to open an in-memory database")
+	}
+
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	file, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.CloseDB(t, file)
+	if !file.Persistent() {
+		t.Error("expected file path to open a persistent database")
+	}
+}
+
+func TestDB_ReplaceData(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	data := &parser.ParsedData{
+		Columns: []parser.Column{{Name: "id", Type: parser.TypeInteger}},
+		Rows:    [][]any{{int64(1)}, {int64(2)}},
+	}
+	for range 2 {
+		if err := database.ReplaceData("test", data); err != nil {
+			t.Fatalf("ReplaceData failed: %v", err)
+		}
+	}
+
+	var count int
+	if err := database.QueryRow("SELECT COUNT(*) FROM test").Scan(&count); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rows after replace, got %d", count)
+	}
+}
+
+func TestDB_RecordSource(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	info, err := database.LoadedSource("test")
+	if err != nil {
+		t.Fatalf("LoadedSource failed: %v", err)
+	}
+	if info != nil {
+		t.Fatalf("expected no source, got %+v", info)
+	}
+
+	want := &db.SourceInfo{Path: "/data/test.json", Options: "format=json", Size: 10, ModTime: 42, Hash: "abc"}
+	if err := database.RecordSource("test", want); err != nil {
+		t.Fatalf("RecordSource failed: %v", err)
+	}
+
+	// The record is ignored while the table does not exist
+	if info, _ := database.LoadedSource("test"); info != nil {
+		t.Errorf("expected no source for missing table, got %+v", info)
+	}
+
+	if _, err := database.Exec("CREATE TABLE test (id INTEGER)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	got, err := database.LoadedSource("test")
+	if err != nil {
+		t.Fatalf("LoadedSource failed: %v", err)
+	}
+	if got == nil || *got != *want {
+		t.Errorf("LoadedSource() = %+v, want %+v", got, want)
+	}
+	if !got.SameFile(&db.SourceInfo{Path: "/data/test.json", Options: "format=json", Size: 10}) {
+		t.Error("expected SameFile to ignore modification time and hash")
+	}
+}
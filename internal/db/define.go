@@ -0,0 +1,280 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+// Definition is a user-defined SQL function whose body is a SQL expression
+// over its parameters, written as:
+//
+//	define fn tier(x) = CASE WHEN x > 100 THEN 'gold' ELSE 'std' END;
+type Definition struct {
+	Name   string
+	Params []string
+	Body   string
+	Line   int // line of the definition in its source, for error messages
+}
+
+// ParseDefinitions parses a sequence of "define fn" statements separated by semicolons.
+// Comments and blank lines are ignored.
+func ParseDefinitions(src string) ([]Definition, error) {
+	tokens := sqllex.Tokenize(src)
+	var defs []Definition
+
+	for len(tokens) > 0 {
+		// Split off one statement
+		n := 0
+		for n < len(tokens) && !tokens[n].Is(";") {
+			n++
+		}
+		stmt := tokens[:n]
+		if n < len(tokens) {
+			n++
+		}
+		tokens = tokens[n:]
+		if len(stmt) == 0 {
+			continue
+		}
+
+		def, err := parseDefinition(src, stmt)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineOf(src, stmt[0].Pos), err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// parseDefinition parses the tokens of one "define fn name(params) = body" statement.
+func parseDefinition(src string, stmt []sqllex.Token) (Definition, error) {
+	def := Definition{Line: lineOf(src, stmt[0].Pos)}
+
+	i := 0
+	next := func() (sqllex.Token, bool) {
+		if i >= len(stmt) {
+			return sqllex.Token{}, false
+		}
+		i++
+		return stmt[i-1], true
+	}
+
+	if tok, _ := next(); !tok.Is("define") {
+		return def, fmt.Errorf("expected \"define fn\", got %q", tok.Text)
+	}
+	if tok, _ := next(); !tok.Is("fn") && !tok.Is("function") {
+		return def, fmt.Errorf("expected \"fn\" after \"define\", got %q", tok.Text)
+	}
+
+	tok, _ := next()
+	if tok.Kind != sqllex.Word {
+		return def, fmt.Errorf("expected function name, got %q", tok.Text)
+	}
+	def.Name = tok.Text
+
+	if tok, _ := next(); !tok.Is("(") {
+		return def, fmt.Errorf("expected \"(\" after %s", def.Name)
+	}
+	for {
+		tok, ok := next()
+		if !ok {
+			return def, fmt.Errorf("unterminated parameter list of %s", def.Name)
+		}
+		if tok.Is(")") && len(def.Params) == 0 {
+			break
+		}
+		if !tok.IsIdent() {
+			return def, fmt.Errorf("expected parameter name in %s, got %q", def.Name, tok.Text)
+		}
+		def.Params = append(def.Params, tok.Ident())
+
+		tok, _ = next()
+		if tok.Is(")") {
+			break
+		}
+		if !tok.Is(",") {
+			return def, fmt.Errorf("expected \",\" or \")\" in parameters of %s, got %q", def.Name, tok.Text)
+		}
+	}
+
+	if tok, _ := next(); !tok.Is("=") {
+		return def, fmt.Errorf("expected \"=\" after parameters of %s", def.Name)
+	}
+	if i >= len(stmt) {
+		return def, fmt.Errorf("function %s has an empty body", def.Name)
+	}
+
+	def.Body = src[stmt[i].Pos:stmt[len(stmt)-1].End]
+	return def, nil
+}
+
+// bindParams returns the body with references to parameters replaced by
+// numbered bind parameters (?1, ?2, ...). Qualified names and function calls
+// are left untouched.
+func bindParams(src string, params []string) string {
+	body := sqllex.Tokenize(src)
+	var b strings.Builder
+	last := 0
+	for i, tok := range body {
+		if !tok.IsIdent() {
+			continue
+		}
+		if i > 0 && body[i-1].Is(".") || i+1 < len(body) && (body[i+1].Is(".") || body[i+1].Is("(")) {
+			continue
+		}
+		for n, param := range params {
+			if strings.EqualFold(tok.Ident(), param) {
+				b.WriteString(src[last:tok.Pos])
+				b.WriteString("?" + strconv.Itoa(n+1))
+				last = tok.End
+				break
+			}
+		}
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// evaluator evaluates the body of a definition on a private in-memory
+// connection, which is opened on first use and again after CloseDefinitions.
+type evaluator struct {
+	query string
+
+	mu   sync.Mutex
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+var (
+	evaluatorsMu sync.Mutex
+	evaluators   []*evaluator
+)
+
+// prepare returns the statement evaluating the body, opening the connection if needed.
+func (e *evaluator) prepare() (*sql.Stmt, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stmt != nil {
+		return e.stmt, nil
+	}
+	db, err := sql.Open("sqlite", memoryPath)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := db.Prepare(e.query)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	e.db, e.stmt = db, stmt
+	return stmt, nil
+}
+
+// close closes the statement and connection, if open.
+func (e *evaluator) close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stmt == nil {
+		return nil
+	}
+	_ = e.stmt.Close()
+	err := e.db.Close()
+	e.db, e.stmt = nil, nil
+	return err
+}
+
+// RegisterDefinition registers a user-defined function. The body is evaluated on a
+// private in-memory connection, so it can use its parameters, built-in functions and
+// functions registered before it, but not tables. The function is registered as
+// nondeterministic, since its body may call functions that are.
+func RegisterDefinition(def Definition) error {
+	e := &evaluator{query: "SELECT " + bindParams(def.Body, def.Params)}
+	if _, err := e.prepare(); err != nil {
+		return fmt.Errorf("invalid body of function %s: %w", def.Name, err)
+	}
+
+	err := RegisterFunction(&Function{
+		Name:             def.Name,
+		Args:             strings.Join(def.Params, ", "),
+		Description:      strings.Join(strings.Fields(def.Body), " "),
+		MinArgs:          len(def.Params),
+		MaxArgs:          len(def.Params),
+		Nondeterministic: true,
+		Scalar: func(args []driver.Value) (driver.Value, error) {
+			stmt, err := e.prepare()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", def.Name, err)
+			}
+			params := make([]any, len(args))
+			for i, arg := range args {
+				params[i] = arg
+			}
+			var result any
+			if err := stmt.QueryRow(params...).Scan(&result); err != nil {
+				return nil, fmt.Errorf("%s: %w", def.Name, err)
+			}
+			return result, nil
+		},
+	})
+	if err != nil {
+		_ = e.close()
+		return err
+	}
+
+	evaluatorsMu.Lock()
+	evaluators = append(evaluators, e)
+	evaluatorsMu.Unlock()
+	return nil
+}
+
+// CloseDefinitions closes the connections that evaluate the bodies of
+// registered definitions. A definition called afterwards opens its
+// connection again.
+func CloseDefinitions() error {
+	evaluatorsMu.Lock()
+	defer evaluatorsMu.Unlock()
+
+	var errs []error
+	for _, e := range evaluators {
+		if err := e.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to close function evaluators: %w", err)
+	}
+	return nil
+}
+
+// LoadDefinitions parses the definitions in a file and registers them in order.
+func LoadDefinitions(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read functions file: %w", err)
+	}
+	defs, err := ParseDefinitions(string(src))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, def := range defs {
+		if err := RegisterDefinition(def); err != nil {
+			return fmt.Errorf("%s: line %d: %w", path, def.Line, err)
+		}
+	}
+	return nil
+}
+
+// lineOf returns the 1-based line number of a byte offset.
+func lineOf(src string, pos int) int {
+	return strings.Count(src[:pos], "\n") + 1
+}
@@ -0,0 +1,155 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestParseDefinitions(t *testing.T) {
+	src := `
+-- pricing tiers
+define fn tier(x) = CASE WHEN x > 100 THEN 'gold' ELSE 'std' END;
+
+define function clamp(v, lo, hi) = max(lo, min(v, hi)); -- trailing comment
+define fn greeting() = 'hi; there'
+`
+	defs, err := db.ParseDefinitions(src)
+	if err != nil {
+		t.Fatalf("ParseDefinitions failed: %v", err)
+	}
+	if len(defs) != 3 {
+		t.Fatalf("expected 3 definitions, got %d", len(defs))
+	}
+
+	want := []db.Definition{
+		{Name: "tier", Params: []string{"x"}, Body: "CASE WHEN x > 100 THEN 'gold' ELSE 'std' END", Line: 3},
+		{Name: "clamp", Params: []string{"v", "lo", "hi"}, Body: "max(lo, min(v, hi))", Line: 5},
+		{Name: "greeting", Body: "'hi; there'", Line: 6},
+	}
+	for i, w := range want {
+		got := defs[i]
+		if got.Name != w.Name || got.Body != w.Body || got.Line != w.Line || strings.Join(got.Params, ",") != strings.Join(w.Params, ",") {
+			t.Errorf("definition %d: got %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestParseDefinitions_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"not a definition", "SELECT 1", `line 1: expected "define fn"`},
+		{"missing name", "define fn (x) = x", "line 1: expected function name"},
+		{"missing equals", "\ndefine fn f(x) x", `line 2: expected "=" after parameters of f`},
+		{"empty body", "define fn f(x) =;", "line 1: function f has an empty body"},
+		{"bad params", "define fn f(x y) = x", `expected "," or ")"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.ParseDefinitions(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// loadTestDefinitions loads test_tier and test_label once per test binary,
+// since driver registrations are global.
+var loadTestDefinitions = sync.OnceValue(func() error {
+	f, err := os.CreateTemp("", "functions-*.sql")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	src := `
+define fn test_tier(x) = CASE WHEN x > 100 THEN 'gold' ELSE 'std' END;
+define fn test_label(name, amount) = upper(name) || ':' || test_tier(amount);
+`
+	if _, err := f.WriteString(src); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return db.LoadDefinitions(f.Name())
+})
+
+func TestLoadDefinitions(t *testing.T) {
+	if err := loadTestDefinitions(); err != nil {
+		t.Fatalf("LoadDefinitions failed: %v", err)
+	}
+
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	_, err = database.Exec(`
+		CREATE TABLE orders (name TEXT, amount INTEGER);
+		INSERT INTO orders VALUES ('alice', 150), ('bob', 20);
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+
+	rows, err := database.Query("SELECT test_label(name, amount) FROM orders ORDER BY name")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		got = append(got, s)
+	}
+	if strings.Join(got, ",") != "ALICE:gold,BOB:std" {
+		t.Errorf("got %v", got)
+	}
+
+	if _, err := database.Exec("SELECT test_tier(1, 2)"); err == nil {
+		t.Error("expected error for wrong number of arguments")
+	}
+
+	// Definitions are nondeterministic, so they cannot be indexed
+	if _, err := database.Exec("CREATE INDEX orders_tier ON orders (test_tier(amount))"); err == nil {
+		t.Error("expected error for indexing a definition")
+	}
+
+	// Closed evaluators are opened again when a definition is called
+	if err := db.CloseDefinitions(); err != nil {
+		t.Fatalf("CloseDefinitions failed: %v", err)
+	}
+	var tier string
+	if err := database.QueryRow("SELECT test_label('carol', 500)").Scan(&tier); err != nil || tier != "CAROL:gold" {
+		t.Errorf("got %q, %v after CloseDefinitions", tier, err)
+	}
+}
+
+func TestLoadDefinitions_InvalidBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "functions.sql")
+	src := "define fn test_self(x) = test_self(x) + 1;"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatalf("failed to write functions file: %v", err)
+	}
+
+	err := db.LoadDefinitions(path)
+	if err == nil || !strings.Contains(err.Error(), "line 1: invalid body of function test_self") {
+		t.Errorf("expected invalid body error, got %v", err)
+	}
+}
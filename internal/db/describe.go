@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+// ColumnInfo describes a column of a table and the values it holds.
+type ColumnInfo struct {
+	Name     string
+	Type     parser.DataType
+	Nullable bool  // the column holds NULL values
+	Distinct int64 // number of distinct non-NULL values
+	Example  any   // first non-NULL value, or nil if there is none
+}
+
+// TableColumns returns the columns of a table in the given schema ("main" for
+// loaded data). Tables loaded in this session report the types inferred from
+// their source; other types are derived from the declared column types.
+func (db *DB) TableColumns(schema, table string) ([]parser.Column, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT name, type FROM %s.pragma_table_info(?)", quoteIdent(schema)), table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var loaded map[string]parser.DataType
+	if schema == "main" {
+		loaded = make(map[string]parser.DataType)
+		for _, col := range db.loadedColumns[table] {
+			loaded[col.Name] = col.Type
+		}
+	}
+
+	var columns []parser.Column
+	for rows.Next() {
+		var name, declType string
+		if err := rows.Scan(&name, &declType); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		typ, ok := loaded[name]
+		if !ok {
+			typ = declaredType(declType)
+		}
+		columns = append(columns, parser.Column{Name: name, Type: typ})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no such table: %s.%s", schema, table)
+	}
+	return columns, nil
+}
+
+// DescribeTable returns the columns of a table with a summary of their values.
+func (db *DB) DescribeTable(schema, table string) ([]ColumnInfo, error) {
+	columns, err := db.TableColumns(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	from := quoteIdent(schema) + "." + quoteIdent(table)
+	exprs := []string{"count(*)"}
+	for _, col := range columns {
+		c := quoteIdent(col.Name)
+		exprs = append(exprs,
+			fmt.Sprintf("count(%s)", c),
+			fmt.Sprintf("count(DISTINCT %s)", c),
+			fmt.Sprintf("(SELECT %s FROM %s WHERE %s IS NOT NULL LIMIT 1)", c, from, c),
+		)
+	}
+
+	var total int64
+	infos := make([]ColumnInfo, len(columns))
+	counts := make([]int64, len(columns))
+	dest := []any{&total}
+	for i, col := range columns {
+		infos[i] = ColumnInfo{Name: col.Name, Type: col.Type}
+		dest = append(dest, &counts[i], &infos[i].Distinct, &infos[i].Example)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(exprs, ", "), from)
+	if err := db.QueryRow(query).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	for i := range infos {
+		infos[i].Nullable = counts[i] < total
+		if n, ok := infos[i].Example.(int64); ok && infos[i].Type == parser.TypeBoolean {
+			infos[i].Example = n != 0
+		}
+	}
+	return infos, nil
+}
+
+// declaredType maps a declared column type to a data type, following
+// SQLite's rules for column affinity.
+func declaredType(declType string) parser.DataType {
+	t := strings.ToUpper(declType)
+	switch {
+	case strings.Contains(t, "BOOL"):
+		return parser.TypeBoolean
+	case strings.Contains(t, "JSON"):
+		return parser.TypeJSON
+	case strings.Contains(t, "INT"):
+		return parser.TypeInteger
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return parser.TypeReal
+	default:
+		return parser.TypeText
+	}
+}
@@ -0,0 +1,83 @@
+package db_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestDB_DescribeTable(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	data := &parser.ParsedData{
+		Columns: []parser.Column{
+			{Name: "id", Type: parser.TypeInteger},
+			{Name: "name", Type: parser.TypeText},
+			{Name: "active", Type: parser.TypeBoolean},
+			{Name: "tags", Type: parser.TypeJSON},
+			{Name: "empty", Type: parser.TypeNull},
+		},
+		Rows: [][]any{
+			{int64(1), nil, true, `["a"]`, nil},
+			{int64(2), "Bob", false, nil, nil},
+			{int64(3), "Bob", true, `["b"]`, nil},
+		},
+	}
+	if err := database.LoadData("users", data); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+
+	got, err := database.DescribeTable("main", "users")
+	if err != nil {
+		t.Fatalf("DescribeTable failed: %v", err)
+	}
+	want := []db.ColumnInfo{
+		{Name: "id", Type: parser.TypeInteger, Distinct: 3, Example: int64(1)},
+		{Name: "name", Type: parser.TypeText, Nullable: true, Distinct: 1, Example: "Bob"},
+		{Name: "active", Type: parser.TypeBoolean, Distinct: 2, Example: true},
+		{Name: "tags", Type: parser.TypeJSON, Nullable: true, Distinct: 2, Example: `["a"]`},
+		{Name: "empty", Type: parser.TypeNull, Nullable: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDB_TableColumns_Declared(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	if _, err := database.Exec("CREATE TABLE t (a INT, b VARCHAR(10), c DOUBLE, d BOOLEAN, e JSON, f)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	got, err := database.TableColumns("main", "t")
+	if err != nil {
+		t.Fatalf("TableColumns failed: %v", err)
+	}
+	want := []parser.Column{
+		{Name: "a", Type: parser.TypeInteger},
+		{Name: "b", Type: parser.TypeText},
+		{Name: "c", Type: parser.TypeReal},
+		{Name: "d", Type: parser.TypeBoolean},
+		{Name: "e", Type: parser.TypeJSON},
+		{Name: "f", Type: parser.TypeText},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := database.TableColumns("main", "missing"); err == nil {
+		t.Error("expected error for missing table")
+	}
+}
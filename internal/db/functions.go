@@ -0,0 +1,199 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"modernc.org/sqlite"
+)
+
+// Function describes a SQL function provided by qo.
+// Exactly one of Scalar or Aggregate must be set.
+type Function struct {
+	Name        string
+	Args        string // argument list for documentation, e.g. "text, pattern[, flags]"
+	Description string
+	MinArgs     int
+	MaxArgs     int // -1 for variadic
+
+	// Nondeterministic marks functions that may return different results for
+	// the same arguments, so SQLite neither reuses their results nor allows them
+	// in indexes and generated columns.
+	Nondeterministic bool
+
+	Scalar    func(args []driver.Value) (driver.Value, error)
+	Aggregate func() Aggregator
+}
+
+// Kind returns "scalar" or "aggregate".
+func (f *Function) Kind() string {
+	if f.Aggregate != nil {
+		return "aggregate"
+	}
+	return "scalar"
+}
+
+// Signature returns the function call form, e.g. "regexp_like(text, pattern[, flags])".
+func (f *Function) Signature() string {
+	return fmt.Sprintf("%s(%s)", f.Name, f.Args)
+}
+
+// Aggregator accumulates the rows of one aggregate function invocation.
+type Aggregator interface {
+	Step(args []driver.Value) error
+	Value() (driver.Value, error)
+}
+
+var (
+	functionsMu sync.Mutex
+	functions   = make(map[string]*Function)
+)
+
+// RegisterFunction registers a function on the SQLite driver and adds it to
+// the function listing. Functions are available to connections opened after
+// registration, so register them before calling Open.
+func RegisterFunction(fn *Function) error {
+	if (fn.Scalar == nil) == (fn.Aggregate == nil) {
+		return fmt.Errorf("function %s: exactly one of Scalar or Aggregate must be set", fn.Name)
+	}
+
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+
+	name := strings.ToLower(fn.Name)
+	if _, ok := functions[name]; ok {
+		return fmt.Errorf("function %s is already registered", fn.Name)
+	}
+
+	nArgs := int32(-1)
+	if fn.MinArgs == fn.MaxArgs {
+		nArgs = int32(fn.MinArgs)
+	}
+	impl := &sqlite.FunctionImpl{NArgs: nArgs, Deterministic: !fn.Nondeterministic}
+
+	if fn.Scalar != nil {
+		impl.Scalar = func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			if err := fn.checkArgs(args); err != nil {
+				return nil, err
+			}
+			return fn.Scalar(args)
+		}
+	} else {
+		impl.MakeAggregate = func(sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+			return &aggregateAdapter{fn: fn, agg: fn.Aggregate()}, nil
+		}
+	}
+
+	if err := sqlite.RegisterFunction(name, impl); err != nil {
+		return fmt.Errorf("failed to register function %s: %w", fn.Name, err)
+	}
+	functions[name] = fn
+	return nil
+}
+
+// MustRegisterFunction is like RegisterFunction but panics on error.
+func MustRegisterFunction(fn *Function) {
+	if err := RegisterFunction(fn); err != nil {
+		panic(err)
+	}
+}
+
+// Functions returns all registered functions sorted by name.
+func Functions() []*Function {
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+
+	list := make([]*Function, 0, len(functions))
+	for _, fn := range functions {
+		list = append(list, fn)
+	}
+	slices.SortFunc(list, func(a, b *Function) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// checkArgs returns an error if the number of arguments is out of range.
+func (f *Function) checkArgs(args []driver.Value) error {
+	if len(args) < f.MinArgs || (f.MaxArgs >= 0 && len(args) > f.MaxArgs) {
+		return fmt.Errorf("wrong number of arguments to function %s()", f.Name)
+	}
+	return nil
+}
+
+// aggregateAdapter adapts an Aggregator to the driver's aggregate interface.
+type aggregateAdapter struct {
+	fn  *Function
+	agg Aggregator
+}
+
+func (a *aggregateAdapter) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	if err := a.fn.checkArgs(args); err != nil {
+		return err
+	}
+	return a.agg.Step(args)
+}
+
+func (a *aggregateAdapter) WindowInverse(*sqlite.FunctionContext, []driver.Value) error {
+	return fmt.Errorf("%s() cannot be used as a sliding window function", a.fn.Name)
+}
+
+func (a *aggregateAdapter) WindowValue(*sqlite.FunctionContext) (driver.Value, error) {
+	return a.agg.Value()
+}
+
+func (a *aggregateAdapter) Final(*sqlite.FunctionContext) {}
+
+// toText converts a SQL value to text. It returns false for NULL.
+func toText(v driver.Value) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// toFloat converts a SQL value to a number. It returns false for NULL and non-numeric values.
+func toFloat(v driver.Value) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string, []byte:
+		s, _ := toText(v)
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toInt converts a SQL value to an integer. It returns false for NULL and non-integer values.
+func toInt(v driver.Value) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	case string, []byte:
+		s, _ := toText(v)
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
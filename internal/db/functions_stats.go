@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// init registers the statistical aggregate functions.
+func init() {
+	MustRegisterFunction(&Function{
+		Name: "median", Args: "x", MinArgs: 1, MaxArgs: 1,
+		Description: "Median of numeric values",
+		Aggregate:   func() Aggregator { return &percentileAgg{name: "median", fixed: 50} },
+	})
+	MustRegisterFunction(&Function{
+		Name: "percentile", Args: "x, p", MinArgs: 2, MaxArgs: 2,
+		Description: "p-th percentile (0-100) of numeric values, interpolated",
+		Aggregate:   func() Aggregator { return &percentileAgg{name: "percentile", fixed: -1} },
+	})
+	MustRegisterFunction(&Function{
+		Name: "variance", Args: "x", MinArgs: 1, MaxArgs: 1,
+		Description: "Sample variance of numeric values",
+		Aggregate:   func() Aggregator { return &varianceAgg{} },
+	})
+	MustRegisterFunction(&Function{
+		Name: "stddev", Args: "x", MinArgs: 1, MaxArgs: 1,
+		Description: "Sample standard deviation of numeric values",
+		Aggregate:   func() Aggregator { return &varianceAgg{stddev: true} },
+	})
+	MustRegisterFunction(&Function{
+		Name: "mode", Args: "x", MinArgs: 1, MaxArgs: 1,
+		Description: "Most frequent non-NULL value (first seen wins ties)",
+		Aggregate:   func() Aggregator { return &modeAgg{counts: make(map[any]int)} },
+	})
+}
+
+// percentileAgg computes an interpolated percentile.
+// fixed is the percentile to compute, or -1 to take it from the second argument.
+type percentileAgg struct {
+	name   string
+	fixed  float64
+	p      float64
+	values []float64
+}
+
+func (a *percentileAgg) Step(args []driver.Value) error {
+	if a.fixed < 0 && len(a.values) == 0 {
+		p, ok := toFloat(args[1])
+		if !ok || p < 0 || p > 100 {
+			return fmt.Errorf("%s: percentile must be a number between 0 and 100", a.name)
+		}
+		a.p = p
+	}
+	if v, ok := toFloat(args[0]); ok {
+		a.values = append(a.values, v)
+	}
+	return nil
+}
+
+func (a *percentileAgg) Value() (driver.Value, error) {
+	if len(a.values) == 0 {
+		return nil, nil
+	}
+	p := a.p
+	if a.fixed >= 0 {
+		p = a.fixed
+	}
+
+	sorted := slices.Clone(a.values)
+	slices.Sort(sorted)
+
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac, nil
+}
+
+// varianceAgg computes the sample variance using Welford's algorithm.
+type varianceAgg struct {
+	stddev bool
+	n      int
+	mean   float64
+	m2     float64
+}
+
+func (a *varianceAgg) Step(args []driver.Value) error {
+	v, ok := toFloat(args[0])
+	if !ok {
+		return nil
+	}
+	a.n++
+	delta := v - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (v - a.mean)
+	return nil
+}
+
+func (a *varianceAgg) Value() (driver.Value, error) {
+	if a.n < 2 {
+		return nil, nil
+	}
+	variance := a.m2 / float64(a.n-1)
+	if a.stddev {
+		return math.Sqrt(variance), nil
+	}
+	return variance, nil
+}
+
+// modeAgg finds the most frequent value.
+type modeAgg struct {
+	counts map[any]int
+	values []driver.Value // distinct values in order of first appearance
+}
+
+func (a *modeAgg) Step(args []driver.Value) error {
+	v := args[0]
+	if v == nil {
+		return nil
+	}
+	key := modeKey(v)
+	if b, ok := v.([]byte); ok {
+		// Blobs are only valid during the call
+		v = slices.Clone(b)
+	}
+	if _, ok := a.counts[key]; !ok {
+		a.values = append(a.values, v)
+	}
+	a.counts[key]++
+	return nil
+}
+
+func (a *modeAgg) Value() (driver.Value, error) {
+	var best driver.Value
+	bestCount := 0
+	for _, v := range a.values {
+		if count := a.counts[modeKey(v)]; count > bestCount {
+			best, bestCount = v, count
+		}
+	}
+	return best, nil
+}
+
+// blobKey distinguishes blob keys from text keys in modeAgg.
+type blobKey string
+
+// modeKey returns a comparable map key for a value.
+func modeKey(v driver.Value) any {
+	if b, ok := v.([]byte); ok {
+		return blobKey(b)
+	}
+	return v
+}
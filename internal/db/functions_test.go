@@ -0,0 +1,240 @@
+package db_test
+
+import (
+	"database/sql/driver"
+	"sync"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+// registerTestDouble registers test_double(x) once per test binary,
+// since driver registrations are global.
+var registerTestDouble = sync.OnceValue(func() error {
+	return db.RegisterFunction(&db.Function{
+		Name: "test_double", Args: "x", MinArgs: 1, MaxArgs: 1,
+		Scalar: func(args []driver.Value) (driver.Value, error) {
+			if v, ok := args[0].(int64); ok {
+				return v * 2, nil
+			}
+			return nil, nil
+		},
+	})
+})
+
+func TestRegisterFunction(t *testing.T) {
+	if err := registerTestDouble(); err != nil {
+		t.Fatalf("RegisterFunction failed: %v", err)
+	}
+
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	var got int64
+	if err := database.QueryRow("SELECT test_double(21)").Scan(&got); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+
+	if _, err := database.Exec("SELECT test_double(1, 2)"); err == nil {
+		t.Error("expected error for wrong number of arguments")
+	}
+}
+
+func TestRegisterFunction_Invalid(t *testing.T) {
+	if err := db.RegisterFunction(&db.Function{Name: "test_invalid"}); err == nil {
+		t.Error("expected error for function without implementation")
+	}
+
+	dup := &db.Function{
+		Name: "regexp_like", MinArgs: 1, MaxArgs: 1,
+		Scalar: func([]driver.Value) (driver.Value, error) { return nil, nil },
+	}
+	if err := db.RegisterFunction(dup); err == nil {
+		t.Error("expected error for duplicate function")
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	fns := db.Functions()
+	if len(fns) == 0 {
+		t.Fatal("expected registered functions")
+	}
+	for i := 1; i < len(fns); i++ {
+		if fns[i-1].Name > fns[i].Name {
+			t.Errorf("functions not sorted: %s before %s", fns[i-1].Name, fns[i].Name)
+		}
+	}
+
+	kinds := make(map[string]string)
+	for _, fn := range fns {
+		kinds[fn.Name] = fn.Kind()
+	}
+	if kinds["split_part"] != "scalar" {
+		t.Errorf("expected split_part to be scalar, got %q", kinds["split_part"])
+	}
+	if kinds["median"] != "aggregate" {
+		t.Errorf("expected median to be aggregate, got %q", kinds["median"])
+	}
+}
+
+func TestTextFunctions(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	tests := []struct {
+		query string
+		want  any
+	}{
+		{"SELECT split_part('a,b,c', ',', 2)", "b"},
+		{"SELECT split_part('a,b,c', ',', -1)", "c"},
+		{"SELECT split_part('a,b,c', ',', 5)", ""},
+		{"SELECT split_part(NULL, ',', 1)", nil},
+		{"SELECT md5('abc')", "900150983cd24fb0d6963f7d28e17f72"},
+		{"SELECT sha256('abc')", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"SELECT base64_encode('hello')", "aGVsbG8="},
+		{"SELECT base64_decode('aGVsbG8=')", "hello"},
+		{"SELECT url_parse('https://bob@example.com:8080/a/b?x=1#top', 'host')", "example.com"},
+		{"SELECT url_parse('https://example.com:8080/a', 'port')", "8080"},
+		{"SELECT json_extract(url_parse('https://example.com/a?id=7&q=x'), '$.params.id')", "7"},
+		{"SELECT levenshtein('kitten', 'sitting')", int64(3)},
+		{"SELECT levenshtein('', 'abc')", int64(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got any
+			if err := database.QueryRow(tt.query).Scan(&got); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsFunctions(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	_, err = database.Exec(`
+		CREATE TABLE t (x REAL, c TEXT);
+		INSERT INTO t VALUES (1, 'a'), (2, 'b'), (3, 'b'), (4, NULL), (NULL, 'a'), (10, 'b');
+		CREATE TABLE empty (x REAL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  any
+	}{
+		{"SELECT median(x) FROM t", 3.0},
+		{"SELECT percentile(x, 0) FROM t", 1.0},
+		{"SELECT percentile(x, 100) FROM t", 10.0},
+		{"SELECT percentile(x, 25) FROM t", 2.0},
+		{"SELECT variance(x) FROM t", 12.5},
+		{"SELECT stddev(x) FROM (SELECT 2 AS x UNION ALL SELECT 4)", 1.4142135623730951},
+		{"SELECT mode(c) FROM t", "b"},
+		{"SELECT median(x) FROM empty", nil},
+		{"SELECT variance(x) FROM empty", nil},
+		{"SELECT mode(x) FROM empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got any
+			if err := database.QueryRow(tt.query).Scan(&got); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := database.Exec("SELECT percentile(x, 150) FROM t"); err == nil {
+		t.Error("expected error for percentile out of range")
+	}
+}
+
+func TestTimeFunctions(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	tests := []struct {
+		query string
+		want  any
+	}{
+		{"SELECT parse_time('10/Oct/2000:13:55:36 -0700', '02/Jan/2006:15:04:05 -0700')", "2000-10-10T13:55:36-07:00"},
+		{"SELECT parse_time('2024-03-01 09:30', '2006-01-02 15:04', 'Asia/Tokyo')", "2024-03-01T09:30:00+09:00"},
+		{"SELECT parse_time('Mon Jan  2 15:04:05 2006', 'ANSIC')", "2006-01-02T15:04:05Z"},
+		{"SELECT parse_time('not a date', 'DateOnly')", nil},
+		{"SELECT format_time('2024-03-01T09:30:00Z', 'Jan 2, 2006')", "Mar 1, 2024"},
+		{"SELECT format_time('2024-03-01', 'DateTime')", "2024-03-01 00:00:00"},
+		{"SELECT format_time('garbage', 'DateTime')", nil},
+		{"SELECT to_epoch('1970-01-02 00:00:00')", int64(86400)},
+		{"SELECT to_epoch('2024-01-01T09:00:00+09:00')", int64(1704067200)},
+		{"SELECT to_epoch_ms('1970-01-01T00:00:01.5Z')", int64(1500)},
+		{"SELECT from_epoch(86400)", "1970-01-02T00:00:00Z"},
+		{"SELECT from_epoch_ms(1704067200123)", "2024-01-01T00:00:00.123Z"},
+		{"SELECT from_epoch(1.5)", "1970-01-01T00:00:01.5Z"},
+		{"SELECT from_epoch(-1.5)", "1969-12-31T23:59:58.5Z"},
+		{"SELECT from_epoch(10000000000)", "2286-11-20T17:46:40Z"},
+		{"SELECT from_epoch(-10000000000)", "1653-02-10T06:13:20Z"},
+		{"SELECT from_epoch(253402300799)", "9999-12-31T23:59:59Z"},
+		{"SELECT from_epoch(-62167219200)", "0000-01-01T00:00:00Z"},
+		{"SELECT from_epoch_ms(-62167219200000)", "0000-01-01T00:00:00Z"},
+		{"SELECT from_epoch_ms(-1)", "1969-12-31T23:59:59.999Z"},
+		{"SELECT to_epoch(253402300800)", nil},
+		{"SELECT date_trunc('month', '2024-03-15T10:20:30Z')", "2024-03-01T00:00:00Z"},
+		{"SELECT date_trunc('quarter', '2024-08-15T10:20:30Z')", "2024-07-01T00:00:00Z"},
+		{"SELECT date_trunc('week', '2024-03-17T10:20:30Z')", "2024-03-11T00:00:00Z"},
+		{"SELECT date_trunc('hour', '2024-03-15T10:20:30+02:00')", "2024-03-15T10:00:00+02:00"},
+		{"SELECT at_timezone('2024-01-01T00:00:00Z', 'Asia/Tokyo')", "2024-01-01T09:00:00+09:00"},
+		{"SELECT convert_tz('2024-07-01 12:00:00', 'America/New_York', 'UTC')", "2024-07-01T16:00:00Z"},
+		{"SELECT date(date_trunc('year', from_epoch(1704067200)))", "2024-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got any
+			if err := database.QueryRow(tt.query).Scan(&got); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{
+		"SELECT date_trunc('fortnight', '2024-03-15')",
+		"SELECT at_timezone('2024-01-01', 'Mars/Olympus')",
+		"SELECT from_epoch(253402300800)",
+		"SELECT from_epoch(-62167219201)",
+		"SELECT from_epoch_ms(253402300800000)",
+		"SELECT from_epoch(1e300)",
+	} {
+		if _, err := database.Exec(query); err == nil {
+			t.Errorf("expected error for %s", query)
+		}
+	}
+}
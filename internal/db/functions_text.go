@@ -0,0 +1,204 @@
+package db
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// init registers the text and encoding functions.
+func init() {
+	MustRegisterFunction(&Function{
+		Name: "split_part", Args: "text, delimiter, index", MinArgs: 3, MaxArgs: 3,
+		Description: "Part of text at the 1-based index (negative counts from the end)",
+		Scalar:      splitPart,
+	})
+	MustRegisterFunction(&Function{
+		Name: "md5", Args: "value", MinArgs: 1, MaxArgs: 1,
+		Description: "Hex-encoded MD5 digest",
+		Scalar:      hashFunc(func(b []byte) []byte { sum := md5.Sum(b); return sum[:] }),
+	})
+	MustRegisterFunction(&Function{
+		Name: "sha256", Args: "value", MinArgs: 1, MaxArgs: 1,
+		Description: "Hex-encoded SHA-256 digest",
+		Scalar:      hashFunc(func(b []byte) []byte { sum := sha256.Sum256(b); return sum[:] }),
+	})
+	MustRegisterFunction(&Function{
+		Name: "base64_encode", Args: "value", MinArgs: 1, MaxArgs: 1,
+		Description: "Standard base64 encoding",
+		Scalar:      base64Encode,
+	})
+	MustRegisterFunction(&Function{
+		Name: "base64_decode", Args: "text", MinArgs: 1, MaxArgs: 1,
+		Description: "Decode standard base64 (text if valid UTF-8, blob otherwise)",
+		Scalar:      base64Decode,
+	})
+	MustRegisterFunction(&Function{
+		Name: "url_parse", Args: "url[, part]", MinArgs: 1, MaxArgs: 2,
+		Description: "URL components as a JSON object, or a single part (scheme, user, host, port, path, query, fragment)",
+		Scalar:      urlParse,
+	})
+	MustRegisterFunction(&Function{
+		Name: "levenshtein", Args: "a, b", MinArgs: 2, MaxArgs: 2,
+		Description: "Edit distance between two strings",
+		Scalar:      levenshtein,
+	})
+}
+
+// splitPart implements split_part(text, delimiter, index).
+func splitPart(args []driver.Value) (driver.Value, error) {
+	text, ok1 := toText(args[0])
+	delim, ok2 := toText(args[1])
+	index, ok3 := toInt(args[2])
+	if !ok1 || !ok2 || !ok3 {
+		return nil, nil
+	}
+	if index == 0 {
+		return nil, fmt.Errorf("split_part: index must not be zero")
+	}
+
+	var parts []string
+	if delim == "" {
+		parts = []string{text}
+	} else {
+		parts = strings.Split(text, delim)
+	}
+
+	if index < 0 {
+		index += int64(len(parts)) + 1
+	}
+	if index < 1 || index > int64(len(parts)) {
+		return "", nil
+	}
+	return parts[index-1], nil
+}
+
+// hashFunc returns a function that hex-encodes the digest of its argument.
+func hashFunc(sum func([]byte) []byte) func([]driver.Value) (driver.Value, error) {
+	return func(args []driver.Value) (driver.Value, error) {
+		b, ok := toBytes(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return hex.EncodeToString(sum(b)), nil
+	}
+}
+
+// base64Encode implements base64_encode(value).
+func base64Encode(args []driver.Value) (driver.Value, error) {
+	b, ok := toBytes(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// base64Decode implements base64_decode(text).
+func base64Decode(args []driver.Value) (driver.Value, error) {
+	s, ok := toText(args[0])
+	if !ok {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("base64_decode: %w", err)
+	}
+	if utf8.Valid(b) {
+		return string(b), nil
+	}
+	return b, nil
+}
+
+// urlParse implements url_parse(url[, part]).
+func urlParse(args []driver.Value) (driver.Value, error) {
+	raw, ok := toText(args[0])
+	if !ok {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, nil
+	}
+
+	parts := map[string]string{
+		"scheme":   u.Scheme,
+		"user":     u.User.Username(),
+		"host":     u.Hostname(),
+		"port":     u.Port(),
+		"path":     u.Path,
+		"query":    u.RawQuery,
+		"fragment": u.Fragment,
+	}
+
+	if len(args) == 2 {
+		name, ok := toText(args[1])
+		if !ok {
+			return nil, nil
+		}
+		part, ok := parts[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("url_parse: unknown part %q", name)
+		}
+		return part, nil
+	}
+
+	params := make(map[string]string)
+	for key, values := range u.Query() {
+		params[key] = values[0]
+	}
+	obj := make(map[string]any, len(parts)+1)
+	for key, value := range parts {
+		obj[key] = value
+	}
+	obj["params"] = params
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// levenshtein implements levenshtein(a, b) over Unicode code points.
+func levenshtein(args []driver.Value) (driver.Value, error) {
+	s1, ok1 := toText(args[0])
+	s2, ok2 := toText(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	a, b := []rune(s1), []rune(s2)
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return int64(prev[len(b)]), nil
+}
+
+// toBytes converts a SQL value to bytes. It returns false for NULL.
+func toBytes(v driver.Value) ([]byte, bool) {
+	if b, ok := v.([]byte); ok {
+		return b, true
+	}
+	s, ok := toText(v)
+	return []byte(s), ok
+}
@@ -0,0 +1,308 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // timezone conversion must not depend on the host's zoneinfo
+)
+
+// timestampLayout is the layout of timestamps returned by the time functions.
+// It is understood by SQLite's built-in date and time functions.
+const timestampLayout = time.RFC3339Nano
+
+// namedLayouts maps layout names accepted in place of Go layouts.
+var namedLayouts = map[string]string{
+	"ansic":       time.ANSIC,
+	"unixdate":    time.UnixDate,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"kitchen":     time.Kitchen,
+	"datetime":    time.DateTime,
+	"dateonly":    time.DateOnly,
+	"timeonly":    time.TimeOnly,
+}
+
+// timestampInputLayouts are tried in order when a function receives a text timestamp.
+var timestampInputLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// init registers the date and time functions.
+func init() {
+	MustRegisterFunction(&Function{
+		Name: "parse_time", Args: "text, layout[, timezone]", MinArgs: 2, MaxArgs: 3,
+		Description: "Parse text with a Go layout into an RFC 3339 timestamp (NULL if it does not match)",
+		Scalar:      parseTime,
+	})
+	MustRegisterFunction(&Function{
+		Name: "format_time", Args: "ts, layout", MinArgs: 2, MaxArgs: 2,
+		Description: "Format a timestamp with a Go layout",
+		Scalar:      formatTime,
+	})
+	MustRegisterFunction(&Function{
+		Name: "to_epoch", Args: "ts", MinArgs: 1, MaxArgs: 1,
+		Description: "Unix time in seconds",
+		Scalar:      toEpoch(time.Time.Unix),
+	})
+	MustRegisterFunction(&Function{
+		Name: "to_epoch_ms", Args: "ts", MinArgs: 1, MaxArgs: 1,
+		Description: "Unix time in milliseconds",
+		Scalar:      toEpoch(time.Time.UnixMilli),
+	})
+	MustRegisterFunction(&Function{
+		Name: "from_epoch", Args: "seconds", MinArgs: 1, MaxArgs: 1,
+		Description: "UTC timestamp from Unix time in seconds",
+		Scalar:      fromEpoch(time.Second),
+	})
+	MustRegisterFunction(&Function{
+		Name: "from_epoch_ms", Args: "milliseconds", MinArgs: 1, MaxArgs: 1,
+		Description: "UTC timestamp from Unix time in milliseconds",
+		Scalar:      fromEpoch(time.Millisecond),
+	})
+	MustRegisterFunction(&Function{
+		Name: "date_trunc", Args: "unit, ts", MinArgs: 2, MaxArgs: 2,
+		Description: "Truncate to year, quarter, month, week, day, hour, minute or second",
+		Scalar:      dateTrunc,
+	})
+	MustRegisterFunction(&Function{
+		Name: "at_timezone", Args: "ts, timezone", MinArgs: 2, MaxArgs: 2,
+		Description: "The same instant in another timezone (e.g. 'Asia/Tokyo')",
+		Scalar:      atTimezone,
+	})
+	MustRegisterFunction(&Function{
+		Name: "convert_tz", Args: "ts, from_timezone, to_timezone", MinArgs: 3, MaxArgs: 3,
+		Description: "Interpret a timestamp without offset in one timezone and convert it to another",
+		Scalar:      convertTZ,
+	})
+}
+
+// parseTime implements parse_time(text, layout[, timezone]).
+// Text without an offset is interpreted in timezone (default: UTC).
+func parseTime(args []driver.Value) (driver.Value, error) {
+	text, ok1 := toText(args[0])
+	layout, ok2 := toText(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+
+	loc := time.UTC
+	if len(args) == 3 {
+		name, ok := toText(args[2])
+		if !ok {
+			return nil, nil
+		}
+		var err error
+		if loc, err = loadLocation(name); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := time.ParseInLocation(resolveLayout(layout), text, loc)
+	if err != nil {
+		return nil, nil
+	}
+	return t.Format(timestampLayout), nil
+}
+
+// formatTime implements format_time(ts, layout).
+func formatTime(args []driver.Value) (driver.Value, error) {
+	t, ok := toTime(args[0])
+	layout, ok2 := toText(args[1])
+	if !ok || !ok2 {
+		return nil, nil
+	}
+	return t.Format(resolveLayout(layout)), nil
+}
+
+// toEpoch returns a function converting a timestamp to Unix time.
+func toEpoch(unix func(time.Time) int64) func([]driver.Value) (driver.Value, error) {
+	return func(args []driver.Value) (driver.Value, error) {
+		t, ok := toTime(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return unix(t), nil
+	}
+}
+
+// fromEpoch returns a function converting Unix time in the given unit to a timestamp.
+func fromEpoch(unit time.Duration) func([]driver.Value) (driver.Value, error) {
+	return func(args []driver.Value) (driver.Value, error) {
+		v, ok := toFloat(args[0])
+		if !ok {
+			return nil, nil
+		}
+		t, err := epochTime(v, unit)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(timestampLayout), nil
+	}
+}
+
+// dateTrunc implements date_trunc(unit, ts), keeping the timestamp's offset.
+func dateTrunc(args []driver.Value) (driver.Value, error) {
+	unit, ok1 := toText(args[0])
+	t, ok2 := toTime(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch strings.ToLower(unit) {
+	case "year":
+		t = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	case "quarter":
+		t = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case "month":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case "week":
+		// ISO weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		t = time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case "day":
+		t = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case "hour":
+		t = time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case "minute":
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case "second":
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	default:
+		return nil, fmt.Errorf("date_trunc: unsupported unit %q", unit)
+	}
+	return t.Format(timestampLayout), nil
+}
+
+// atTimezone implements at_timezone(ts, timezone).
+func atTimezone(args []driver.Value) (driver.Value, error) {
+	t, ok1 := toTime(args[0])
+	name, ok2 := toText(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	loc, err := loadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.In(loc).Format(timestampLayout), nil
+}
+
+// convertTZ implements convert_tz(ts, from_timezone, to_timezone).
+// An offset present in ts takes precedence over from_timezone.
+func convertTZ(args []driver.Value) (driver.Value, error) {
+	from, ok1 := toText(args[1])
+	to, ok2 := toText(args[2])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	fromLoc, err := loadLocation(from)
+	if err != nil {
+		return nil, err
+	}
+	toLoc, err := loadLocation(to)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := toTimeIn(args[0], fromLoc)
+	if !ok {
+		return nil, nil
+	}
+	return t.In(toLoc).Format(timestampLayout), nil
+}
+
+// resolveLayout returns the Go layout for a layout name, or the layout itself.
+func resolveLayout(layout string) string {
+	if named, ok := namedLayouts[strings.ToLower(layout)]; ok {
+		return named
+	}
+	return layout
+}
+
+// toTime converts a SQL value to a time. Text timestamps without an offset are UTC
+// and numbers are Unix time in seconds. It returns false for NULL and unparsable values.
+func toTime(v driver.Value) (time.Time, bool) {
+	return toTimeIn(v, time.UTC)
+}
+
+// toTimeIn is like toTime, but interprets text timestamps without an offset in loc.
+func toTimeIn(v driver.Value, loc *time.Location) (time.Time, bool) {
+	switch v := v.(type) {
+	case int64:
+		t, err := epochTime(float64(v), time.Second)
+		return t, err == nil
+	case float64:
+		t, err := epochTime(v, time.Second)
+		return t, err == nil
+	case string, []byte:
+		s, _ := toText(v)
+		s = strings.TrimSpace(s)
+		for _, layout := range timestampInputLayouts {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// minEpoch and maxEpoch bound the Unix times in seconds of the timestamps
+// RFC 3339 can represent, in years 0000 to 9999.
+var (
+	minEpoch = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	maxEpoch = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+// epochTime converts a Unix time in the given unit to a UTC time. It fails for
+// times outside the years RFC 3339 can represent.
+func epochTime(v float64, unit time.Duration) (time.Time, error) {
+	perSecond := int64(time.Second / unit)
+	if seconds := v / float64(perSecond); !(seconds >= float64(minEpoch) && seconds < float64(maxEpoch)) {
+		return time.Time{}, fmt.Errorf("unix time %.15g is out of range (years 0000 to 9999)", v)
+	}
+
+	// Split whole seconds from the rest so that no part overflows a Duration
+	whole, frac := math.Modf(v)
+	units := int64(whole)
+	nsec := (units%perSecond)*int64(unit) + int64(math.Round(frac*float64(unit)))
+	return time.Unix(units/perSecond, nsec).UTC(), nil
+}
+
+var (
+	locationsMu sync.Mutex
+	locations   = make(map[string]*time.Location)
+)
+
+// loadLocation returns the timezone with the given IANA name, caching lookups.
+func loadLocation(name string) (*time.Location, error) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+
+	if loc, ok := locations[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	locations[name] = loc
+	return loc, nil
+}
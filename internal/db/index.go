@@ -0,0 +1,280 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+// Index identifies an indexed column.
+type Index struct {
+	Table  string
+	Column string
+}
+
+// ParseIndex parses an index specification of the form "table.column".
+func ParseIndex(spec string) (Index, error) {
+	table, column, ok := strings.Cut(spec, ".")
+	if !ok || table == "" || column == "" {
+		return Index{}, fmt.Errorf("invalid index %q: expected table.column", spec)
+	}
+	return Index{Table: table, Column: column}, nil
+}
+
+// name returns the name of the index created by qo for the column. The hash
+// of the table and column tells apart names that join to the same text, such
+// as a_b.c and a.b_c.
+func (idx Index) name() string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(idx.Table + "\x00" + idx.Column))
+	return fmt.Sprintf("qo_idx_%s_%s_%08x", idx.Table, idx.Column, h.Sum32())
+}
+
+// CreateIndex creates an index on the column unless qo has already created one.
+func (db *DB) CreateIndex(idx Index) error {
+	return db.createIndex(context.Background(), idx)
+}
+
+func (db *DB) createIndex(ctx context.Context, idx Index) error {
+	// SQLite reads a quoted name that matches no column as a string, so check first
+	columns, err := db.columnNames(ctx, idx.Table)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(columns, func(name string) bool { return strings.EqualFold(name, idx.Column) })
+	if i < 0 {
+		return fmt.Errorf("failed to create index on %s.%s: no such column", idx.Table, idx.Column)
+	}
+	idx.Column = columns[i]
+
+	createSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", quoteIdent(idx.name()), quoteIdent(idx.Table), quoteIdent(idx.Column))
+	if _, err := db.ExecContext(ctx, createSQL); err != nil {
+		return fmt.Errorf("failed to create index on %s.%s: %w", idx.Table, idx.Column, err)
+	}
+	return nil
+}
+
+// AutoIndex creates indexes on columns that the query compares in JOIN and
+// WHERE clauses, and returns them. Only tables in the main schema are indexed;
+// references that do not resolve to an existing column are ignored, so
+// incomplete queries are safe to pass.
+func (db *DB) AutoIndex(query string) ([]Index, error) {
+	return db.AutoIndexContext(context.Background(), query)
+}
+
+// AutoIndexContext is like AutoIndex but stops creating indexes when ctx is done.
+func (db *DB) AutoIndexContext(ctx context.Context, query string) ([]Index, error) {
+	refs := columnRefs(sqllex.Tokenize(query))
+	if len(refs.columns) == 0 {
+		return nil, nil
+	}
+
+	columnsByTable := make(map[string][]string)
+	var created []Index
+	seen := make(map[Index]bool)
+
+	for _, ref := range refs.columns {
+		tables := refs.tablesFor(ref.qualifier)
+		for _, table := range tables {
+			columns, ok := columnsByTable[table]
+			if !ok {
+				var err error
+				if columns, err = db.columnNames(ctx, table); err != nil {
+					return created, err
+				}
+				columnsByTable[table] = columns
+			}
+
+			for _, column := range columns {
+				idx := Index{Table: table, Column: column}
+				if !strings.EqualFold(column, ref.column) || seen[idx] {
+					continue
+				}
+				seen[idx] = true
+				if err := db.createIndex(ctx, idx); err != nil {
+					return created, err
+				}
+				created = append(created, idx)
+			}
+		}
+	}
+	return created, nil
+}
+
+// columnNames returns the column names of a table in the main schema.
+// It returns no columns if the table does not exist.
+func (db *DB) columnNames(ctx context.Context, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?, 'main')", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// columnRef is a possibly qualified column reference in a query.
+type columnRef struct {
+	qualifier string // table name or alias, empty if unqualified
+	column    string
+}
+
+// queryRefs holds the tables and compared columns found in a query.
+type queryRefs struct {
+	tables  map[string]string // lower-cased alias or table name -> table name
+	order   []string          // table names in order of appearance
+	columns []columnRef
+}
+
+// tablesFor returns the tables a qualifier may refer to.
+// An unqualified reference may belong to any table in the query.
+func (r *queryRefs) tablesFor(qualifier string) []string {
+	if qualifier != "" {
+		if table, ok := r.tables[strings.ToLower(qualifier)]; ok {
+			return []string{table}
+		}
+		return nil
+	}
+
+	return r.order
+}
+
+// comparisonOperators lists operators whose operands can benefit from an index.
+var comparisonOperators = []string{"=", "==", "<", ">", "<=", ">=", "IN", "BETWEEN", "IS"}
+
+// clauseKeywords end the region in which comparisons are collected.
+var clauseKeywords = []string{
+	"SELECT", "FROM", "JOIN", "GROUP", "ORDER", "LIMIT", "HAVING", "WINDOW", "UNION", "EXCEPT", "INTERSECT",
+}
+
+// aliasStopWords are keywords that may follow a table name but are not aliases.
+var aliasStopWords = []string{
+	"WHERE", "ON", "USING", "JOIN", "LEFT", "RIGHT", "FULL", "INNER", "OUTER", "CROSS", "NATURAL",
+	"GROUP", "ORDER", "LIMIT", "HAVING", "WINDOW", "UNION", "EXCEPT", "INTERSECT", "INDEXED", "NOT", "AS",
+}
+
+// columnRefs collects table aliases and the columns compared in ON, USING and WHERE clauses.
+func columnRefs(tokens []sqllex.Token) *queryRefs {
+	refs := &queryRefs{tables: make(map[string]string)}
+	inCondition := false
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.Is("WHERE") || tok.Is("ON"):
+			inCondition = true
+		case tok.Is("FROM") || tok.Is("JOIN"):
+			inCondition = false
+			i = refs.collectTables(tokens, i+1) - 1
+		case tok.Is("USING") && i+1 < len(tokens) && tokens[i+1].Is("("):
+			for i += 2; i < len(tokens) && !tokens[i].Is(")"); i++ {
+				if tokens[i].IsIdent() {
+					refs.columns = append(refs.columns, columnRef{column: tokens[i].Ident()})
+				}
+			}
+		case isAnyOf(tok, clauseKeywords):
+			inCondition = false
+		case inCondition && isAnyOf(tok, comparisonOperators):
+			if ref, ok := columnBefore(tokens, i); ok {
+				refs.columns = append(refs.columns, ref)
+			}
+			if ref, ok := columnAfter(tokens, i); ok {
+				refs.columns = append(refs.columns, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// collectTables records the comma-separated table references starting at i
+// and returns the index of the first token after them.
+func (r *queryRefs) collectTables(tokens []sqllex.Token, i int) int {
+	for i < len(tokens) && tokens[i].IsIdent() {
+		name := tokens[i].Ident()
+		i++
+		if i+1 < len(tokens) && tokens[i].Is(".") && tokens[i+1].IsIdent() {
+			// Only tables in the main schema can be indexed
+			if !strings.EqualFold(name, "main") {
+				name = ""
+			} else {
+				name = tokens[i+1].Ident()
+			}
+			i += 2
+		}
+
+		if name != "" {
+			if _, ok := r.tables[strings.ToLower(name)]; !ok {
+				r.order = append(r.order, name)
+			}
+			r.tables[strings.ToLower(name)] = name
+		}
+
+		if i < len(tokens) && tokens[i].Is("AS") {
+			i++
+		}
+		if i < len(tokens) && tokens[i].IsIdent() && !isAnyOf(tokens[i], aliasStopWords) {
+			if name != "" {
+				r.tables[strings.ToLower(tokens[i].Ident())] = name
+			}
+			i++
+		}
+
+		if i >= len(tokens) || !tokens[i].Is(",") {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// columnBefore returns the column reference that ends just before tokens[i].
+func columnBefore(tokens []sqllex.Token, i int) (columnRef, bool) {
+	if i < 1 || !tokens[i-1].IsIdent() {
+		return columnRef{}, false
+	}
+	ref := columnRef{column: tokens[i-1].Ident()}
+	if i >= 3 && tokens[i-2].Is(".") && tokens[i-3].IsIdent() {
+		ref.qualifier = tokens[i-3].Ident()
+	}
+	return ref, true
+}
+
+// columnAfter returns the column reference that starts just after tokens[i].
+func columnAfter(tokens []sqllex.Token, i int) (columnRef, bool) {
+	if i+1 >= len(tokens) || !tokens[i+1].IsIdent() {
+		return columnRef{}, false
+	}
+	ref := columnRef{column: tokens[i+1].Ident()}
+	next := i + 2
+	if i+3 < len(tokens) && tokens[i+2].Is(".") && tokens[i+3].IsIdent() {
+		ref = columnRef{qualifier: tokens[i+1].Ident(), column: tokens[i+3].Ident()}
+		next = i + 4
+	}
+	// A following parenthesis means a function call, not a column
+	if next < len(tokens) && tokens[next].Is("(") {
+		return columnRef{}, false
+	}
+	return ref, true
+}
+
+// isAnyOf reports whether the token is any of the given keywords or operators.
+func isAnyOf(tok sqllex.Token, texts []string) bool {
+	for _, text := range texts {
+		if tok.Is(text) {
+			return true
+		}
+	}
+	return false
+}
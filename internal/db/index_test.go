@@ -0,0 +1,161 @@
+package db_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestParseIndex(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    db.Index
+		wantErr bool
+	}{
+		{spec: "users.id", want: db.Index{Table: "users", Column: "id"}},
+		{spec: "users", wantErr: true},
+		{spec: ".id", wantErr: true},
+		{spec: "users.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := db.ParseIndex(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIndex(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseIndex(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestDB_CreateIndex(t *testing.T) {
+	database := setupIndexDB(t)
+
+	idx := db.Index{Table: "users", Column: "id"}
+	for range 2 {
+		if err := database.CreateIndex(idx); err != nil {
+			t.Fatalf("CreateIndex failed: %v", err)
+		}
+	}
+	if got := indexedColumns(t, database); !slices.Equal(got, []string{"users.id"}) {
+		t.Errorf("indexed columns = %v, want [users.id]", got)
+	}
+
+	if err := database.CreateIndex(db.Index{Table: "users", Column: "missing"}); err == nil {
+		t.Error("expected error for missing column")
+	}
+}
+
+func TestDB_CreateIndex_Names(t *testing.T) {
+	database := setupIndexDB(t)
+	_, err := database.Exec("CREATE TABLE a_b (c INTEGER); CREATE TABLE a (b_c INTEGER); CREATE TABLE [x`y] ([q\"z] INTEGER)")
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	// Names that would join to the same text, and names that need quoting
+	for _, idx := range []db.Index{{Table: "a_b", Column: "c"}, {Table: "a", Column: "b_c"}, {Table: "x`y", Column: `q"z`}} {
+		if err := database.CreateIndex(idx); err != nil {
+			t.Fatalf("CreateIndex(%v) failed: %v", idx, err)
+		}
+	}
+	want := []string{"a.b_c", "a_b.c", "x`y.q\"z"}
+	if got := indexedColumns(t, database); !slices.Equal(got, want) {
+		t.Errorf("indexed columns = %v, want %v", got, want)
+	}
+}
+
+func TestDB_AutoIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "where equality",
+			query: "SELECT * FROM users WHERE id = 1",
+			want:  []string{"users.id"},
+		},
+		{
+			name:  "join with aliases",
+			query: "SELECT * FROM users u JOIN orders AS o ON u.id = o.user_id WHERE o.amount > 100",
+			want:  []string{"orders.amount", "orders.user_id", "users.id"},
+		},
+		{
+			name:  "join using",
+			query: "SELECT * FROM users JOIN orders USING (id)",
+			want:  []string{"orders.id", "users.id"},
+		},
+		{
+			name:  "comma join and IN",
+			query: "SELECT * FROM users, orders WHERE users.id = orders.user_id AND name IN ('a')",
+			want:  []string{"orders.user_id", "users.id", "users.name"},
+		},
+		{
+			name:  "select list and functions are ignored",
+			query: "SELECT id = 1 FROM users WHERE lower(name) = 'a'",
+		},
+		{
+			name:  "unknown columns and incomplete queries are ignored",
+			query: "SELECT * FROM users WHERE nope = 1 AND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := setupIndexDB(t)
+
+			if _, err := database.AutoIndex(tt.query); err != nil {
+				t.Fatalf("AutoIndex failed: %v", err)
+			}
+			if got := indexedColumns(t, database); !slices.Equal(got, tt.want) {
+				t.Errorf("indexed columns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func setupIndexDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	_, err = database.Exec(`
+		CREATE TABLE users (id INTEGER, name TEXT);
+		CREATE TABLE orders (id INTEGER, user_id INTEGER, amount REAL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+	return database
+}
+
+// indexedColumns returns "table.column" for every index column, sorted.
+func indexedColumns(t *testing.T, database *db.DB) []string {
+	t.Helper()
+	rows, err := database.Query(`
+		SELECT m.tbl_name || '.' || i.name FROM sqlite_master m, pragma_index_info(m.name) i
+		WHERE m.type = 'index' ORDER BY 1
+	`)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	testutil.CloseRows(t, rows)
+
+	var got []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		got = append(got, col)
+	}
+	return got
+}
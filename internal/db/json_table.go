@@ -0,0 +1,599 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"modernc.org/sqlite/vtab"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+// jsonTablePrefix names the virtual tables created for json_table column lists.
+const jsonTablePrefix = "qo_json_table_"
+
+// hiddenColumns are the table-valued function arguments, in order.
+var hiddenColumns = []string{"_json", "_path"}
+
+// Virtual table modules behind unnest and json_table. The driver installs
+// registered modules on every connection it opens afterwards.
+const (
+	unnestModule    = "qo_unnest"
+	jsonTableModule = "qo_json_table"
+)
+
+var (
+	registerModulesOnce sync.Once
+	errRegisterModules  error
+)
+
+// registerModules registers the unnest and json_table modules with the
+// driver, once per process.
+func registerModules() error {
+	registerModulesOnce.Do(func() {
+		// The driver ignores the *sql.DB argument; modules apply to all connections
+		if err := vtab.RegisterModule(nil, unnestModule, jsonModule{unnest: true}); err != nil {
+			errRegisterModules = fmt.Errorf("failed to register unnest: %w", err)
+			return
+		}
+		if err := vtab.RegisterModule(nil, jsonTableModule, jsonModule{}); err != nil {
+			errRegisterModules = fmt.Errorf("failed to register json_table: %w", err)
+		}
+	})
+	return errRegisterModules
+}
+
+// initTableFunctions creates the unnest and json_table table-valued functions
+// on the connection. They live in the temp schema so they are never saved.
+func (db *DB) initTableFunctions() error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS temp.unnest USING %s;
+		CREATE VIRTUAL TABLE IF NOT EXISTS temp.json_table USING %s;
+	`, unnestModule, jsonTableModule))
+	if err != nil {
+		return fmt.Errorf("failed to create table functions: %w", err)
+	}
+	return nil
+}
+
+// RewriteQuery prepares a query for execution. Calls of json_table with a
+// column list, json_table(json, path, 'id INT, name TEXT'), are rewritten to
+// virtual tables declaring those columns, which are created as needed.
+func (db *DB) RewriteQuery(query string) (string, error) {
+	tokens := sqllex.Tokenize(query)
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].Is("json_table") || i+1 >= len(tokens) || !tokens[i+1].Is("(") || (i > 0 && tokens[i-1].Is(".")) {
+			continue
+		}
+		args, end := splitCallArgs(tokens, i+1)
+		if len(args) != 3 || len(args[2]) != 1 || args[2][0].Kind != sqllex.String {
+			continue
+		}
+
+		spec := unquoteString(args[2][0].Text)
+		table, err := db.ensureJSONTable(spec)
+		if err != nil {
+			return "", err
+		}
+
+		// json_table(json, path, 'spec') -> table(json, path)
+		b.WriteString(query[last:tokens[i].Pos])
+		b.WriteString(table)
+		b.WriteString(query[tokens[i+1].Pos:args[1][len(args[1])-1].End])
+		b.WriteString(")")
+		last = tokens[end].End
+		i = end
+	}
+	if last == 0 {
+		return query, nil
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// ensureJSONTable creates the virtual table for a json_table column list and returns its name.
+func (db *DB) ensureJSONTable(spec string) (string, error) {
+	if _, err := parseColumnSpec(spec); err != nil {
+		return "", fmt.Errorf("json_table: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(spec))
+	table := jsonTablePrefix + hex.EncodeToString(sum[:4])
+	stmt := fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS temp.%s USING %s(%s)",
+		table, jsonTableModule, quoteString(spec))
+	if _, err := db.Exec(stmt); err != nil {
+		return "", fmt.Errorf("failed to create json_table: %w", err)
+	}
+	return table, nil
+}
+
+// splitCallArgs splits the arguments of a call whose "(" is tokens[open].
+// It returns the tokens of each argument and the index of the closing ")".
+func splitCallArgs(tokens []sqllex.Token, open int) ([][]sqllex.Token, int) {
+	var args [][]sqllex.Token
+	depth := 0
+	start := open + 1
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].Is("("):
+			depth++
+		case tokens[i].Is(")"):
+			depth--
+			if depth == 0 {
+				if i > start {
+					args = append(args, tokens[start:i])
+				}
+				return args, i
+			}
+		case tokens[i].Is(",") && depth == 1:
+			args = append(args, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return nil, len(tokens) - 1
+}
+
+// jsonModule implements vtab.Module for unnest and json_table.
+type jsonModule struct {
+	unnest bool
+}
+
+func (m jsonModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Connect(ctx, args)
+}
+
+// Connect declares the table. args[3], if present, is the json_table column list.
+func (m jsonModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	t := &jsonTable{args: 2}
+	if m.unnest {
+		t.args = 1
+	}
+	if len(args) > 3 {
+		columns, err := parseColumnSpec(unquoteString(strings.TrimSpace(args[3])))
+		if err != nil {
+			return nil, err
+		}
+		t.columns = columns
+	}
+
+	var defs []string
+	if t.columns == nil {
+		defs = append(defs, "value", "idx INTEGER")
+	}
+	for _, col := range t.columns {
+		defs = append(defs, quoteIdent(col.name)+" "+col.typ)
+	}
+	for _, name := range hiddenColumns[:t.args] {
+		defs = append(defs, name+" HIDDEN")
+	}
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(defs, ", "))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// jsonTable is a table-valued function over the elements of a JSON value.
+// Without a column list it has the columns value and idx.
+type jsonTable struct {
+	columns []jsonColumn
+	args    int // number of hidden argument columns
+}
+
+// visible returns the number of visible columns.
+func (t *jsonTable) visible() int {
+	if t.columns == nil {
+		return 2
+	}
+	return len(t.columns)
+}
+
+// BestIndex passes equality constraints on the argument columns to Filter,
+// with bit i of IdxNum set when argument i is present.
+func (t *jsonTable) BestIndex(info *vtab.IndexInfo) error {
+	usable := make([]int, t.args)
+	for i := range usable {
+		usable[i] = -1
+	}
+	for i, c := range info.Constraints {
+		arg := c.Column - t.visible()
+		if arg < 0 || arg >= t.args || c.Op != vtab.OpEQ || !c.Usable {
+			continue
+		}
+		usable[arg] = i
+	}
+
+	// Without the JSON argument this plan yields no rows; make it unattractive
+	if usable[0] < 0 {
+		info.EstimatedCost = 1e12
+		return nil
+	}
+
+	argIndex := 0
+	for arg, i := range usable {
+		if i < 0 {
+			continue
+		}
+		info.Constraints[i].ArgIndex = argIndex
+		info.Constraints[i].Omit = true
+		info.IdxNum |= 1 << arg
+		argIndex++
+	}
+	info.EstimatedCost = 1
+	info.EstimatedRows = 100
+	return nil
+}
+
+func (t *jsonTable) Open() (vtab.Cursor, error) {
+	return &jsonCursor{table: t}, nil
+}
+
+func (t *jsonTable) Disconnect() error { return nil }
+
+func (t *jsonTable) Destroy() error { return nil }
+
+// jsonCursor iterates over the rows selected by the path.
+type jsonCursor struct {
+	table *jsonTable
+	args  []vtab.Value
+	rows  []gjson.Result
+	pos   int
+}
+
+func (c *jsonCursor) Filter(idxNum int, _ string, vals []vtab.Value) error {
+	c.args = make([]vtab.Value, c.table.args)
+	c.rows = nil
+	c.pos = 0
+	for arg, i := 0, 0; arg < c.table.args; arg++ {
+		if idxNum&(1<<arg) != 0 && i < len(vals) {
+			c.args[arg] = vals[i]
+			i++
+		}
+	}
+
+	src, ok := toText(c.args[0])
+	if !ok {
+		return nil
+	}
+	if !gjson.Valid(src) {
+		return fmt.Errorf("malformed JSON")
+	}
+
+	path := "$"
+	if c.table.args > 1 {
+		if p, ok := toText(c.args[1]); ok {
+			path = p
+		}
+	}
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return err
+	}
+
+	matches := evalJSONPath(gjson.Parse(src), steps)
+	if !hasWildcard(steps) && len(matches) == 1 && matches[0].IsArray() {
+		matches = matches[0].Array()
+	}
+	for _, m := range matches {
+		if m.Type != gjson.Null {
+			c.rows = append(c.rows, m)
+		}
+	}
+	return nil
+}
+
+func (c *jsonCursor) Next() error {
+	c.pos++
+	return nil
+}
+
+func (c *jsonCursor) Eof() bool {
+	return c.pos >= len(c.rows)
+}
+
+func (c *jsonCursor) Column(col int) (vtab.Value, error) {
+	visible := c.table.visible()
+	if col >= visible {
+		return c.args[col-visible], nil
+	}
+
+	row := c.rows[c.pos]
+	if c.table.columns == nil {
+		if col == 0 {
+			return jsonValue(row), nil
+		}
+		return int64(c.pos), nil
+	}
+
+	column := c.table.columns[col]
+	var val gjson.Result
+	if column.path != nil {
+		if matches := evalJSONPath(row, column.path); len(matches) > 0 {
+			val = matches[0]
+		}
+	} else {
+		val = objectField(row, column.name)
+	}
+	return column.convert(val), nil
+}
+
+func (c *jsonCursor) Rowid() (int64, error) {
+	return int64(c.pos), nil
+}
+
+func (c *jsonCursor) Close() error { return nil }
+
+// jsonColumn is a column of a json_table column list, e.g. "city TEXT PATH '$.address.city'".
+type jsonColumn struct {
+	name string
+	typ  string
+	path []pathStep // nil: the field named like the column
+}
+
+// parseColumnSpec parses a json_table column list.
+func parseColumnSpec(spec string) ([]jsonColumn, error) {
+	tokens := sqllex.Tokenize(spec)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty column list")
+	}
+
+	var columns []jsonColumn
+	for len(tokens) > 0 {
+		n, depth := 0, 0
+		for ; n < len(tokens); n++ {
+			if tokens[n].Is("(") {
+				depth++
+			} else if tokens[n].Is(")") {
+				depth--
+			} else if tokens[n].Is(",") && depth == 0 {
+				break
+			}
+		}
+		def := tokens[:n]
+		if n < len(tokens) {
+			n++
+		}
+		tokens = tokens[n:]
+
+		if len(def) == 0 || !def[0].IsIdent() {
+			return nil, fmt.Errorf("invalid column list %q", spec)
+		}
+		col := jsonColumn{name: def[0].Ident()}
+		for _, hidden := range hiddenColumns {
+			if strings.EqualFold(col.name, hidden) {
+				return nil, fmt.Errorf("column name %s is reserved", col.name)
+			}
+		}
+
+		typeEnd := len(def)
+		for i := 1; i < len(def); i++ {
+			if !def[i].Is("path") {
+				continue
+			}
+			if i != len(def)-2 || def[i+1].Kind != sqllex.String {
+				return nil, fmt.Errorf("column %s: PATH must be followed by a string", col.name)
+			}
+			steps, err := parseJSONPath(unquoteString(def[i+1].Text))
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.name, err)
+			}
+			col.path = steps
+			typeEnd = i
+			break
+		}
+		if typeEnd > 1 {
+			col.typ = spec[def[1].Pos:def[typeEnd-1].End]
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// convert converts a JSON value according to the column's declared type.
+func (c jsonColumn) convert(val gjson.Result) any {
+	if !val.Exists() || val.Type == gjson.Null {
+		return nil
+	}
+
+	typ := strings.ToUpper(c.typ)
+	switch {
+	case typ == "JSON":
+		return compactJSON(val.Raw)
+	case strings.Contains(typ, "BOOL"):
+		switch val.Type {
+		case gjson.True, gjson.False:
+			return val.Bool()
+		case gjson.Number:
+			return val.Float() != 0
+		}
+		if b, err := strconv.ParseBool(val.String()); err == nil {
+			return b
+		}
+		return nil
+	case strings.Contains(typ, "INT"):
+		if v, ok := toInt(jsonValue(val)); ok {
+			return v
+		}
+		return nil
+	case strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOA"), strings.Contains(typ, "DOUB"), strings.Contains(typ, "NUM"):
+		if v, ok := toFloat(jsonValue(val)); ok {
+			return v
+		}
+		return nil
+	case strings.Contains(typ, "TEXT"), strings.Contains(typ, "CHAR"), strings.Contains(typ, "CLOB"):
+		if val.Type == gjson.String {
+			return val.String()
+		}
+		return compactJSON(val.Raw)
+	default:
+		return jsonValue(val)
+	}
+}
+
+// jsonValue converts a JSON value to a SQL value. Objects and arrays become JSON text.
+func jsonValue(val gjson.Result) any {
+	switch val.Type {
+	case gjson.String:
+		return val.String()
+	case gjson.Number:
+		if float64(int64(val.Float())) == val.Float() {
+			return val.Int()
+		}
+		return val.Float()
+	case gjson.True:
+		return true
+	case gjson.False:
+		return false
+	case gjson.JSON:
+		return compactJSON(val.Raw)
+	default:
+		return nil
+	}
+}
+
+// compactJSON removes insignificant whitespace from JSON text.
+func compactJSON(raw string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		return raw
+	}
+	return buf.String()
+}
+
+// objectField returns the field of a JSON object with the given name.
+func objectField(obj gjson.Result, name string) gjson.Result {
+	var field gjson.Result
+	if !obj.IsObject() {
+		return field
+	}
+	obj.ForEach(func(key, value gjson.Result) bool {
+		if key.String() == name {
+			field = value
+			return false
+		}
+		return true
+	})
+	return field
+}
+
+// pathStep is one step of a JSON path: a field name, an array index or a wildcard.
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a JSON path such as $.items[*].name, $["key"] or $[0].
+func parseJSONPath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			steps = append(steps, pathStep{wildcard: true})
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			n := strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: empty field name", path)
+			}
+			steps = append(steps, pathStep{key: rest[:n]})
+			rest = rest[n:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unterminated [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf("invalid JSON path %q: bad index %q", path, inner)
+				}
+				steps = append(steps, pathStep{index: i, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath returns the values selected by a parsed JSON path.
+func evalJSONPath(root gjson.Result, steps []pathStep) []gjson.Result {
+	current := []gjson.Result{root}
+	for _, step := range steps {
+		var next []gjson.Result
+		for _, val := range current {
+			switch {
+			case step.wildcard:
+				val.ForEach(func(_, v gjson.Result) bool {
+					next = append(next, v)
+					return true
+				})
+			case step.isIndex:
+				if arr := val.Array(); val.IsArray() && step.index < len(arr) {
+					next = append(next, arr[step.index])
+				}
+			default:
+				if field := objectField(val, step.key); field.Exists() {
+					next = append(next, field)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// hasWildcard reports whether a path can select more than one value.
+func hasWildcard(steps []pathStep) bool {
+	for _, step := range steps {
+		if step.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteIdent quotes an identifier for use in SQL.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteString quotes text as a SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// unquoteString removes the quotes of a SQL string literal, if any.
+func unquoteString(s string) string {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return s
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+}
@@ -0,0 +1,199 @@
+package db_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+// setupOrdersDB creates a database with JSON columns to unnest.
+func setupOrdersDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	_, err = database.Exec(`
+		CREATE TABLE orders (id INTEGER, tags TEXT, detail TEXT);
+		INSERT INTO orders VALUES
+			(1, '["new","gift"]', '{"items":[{"sku":"A","qty":2,"meta":{"color":"red"}},{"sku":"B","qty":"1"}]}'),
+			(2, '[]', '{"items":[]}'),
+			(3, NULL, '{"items":[{"sku":"C","qty":1.5}]}');
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+	return database
+}
+
+// queryJSON runs a query and returns its rows encoded as a JSON array of arrays.
+func queryJSON(t *testing.T, database *db.DB, query string) string {
+	t.Helper()
+	query, err := database.RewriteQuery(query)
+	if err != nil {
+		t.Fatalf("RewriteQuery failed: %v", err)
+	}
+	rows, err := database.Query(query)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("columns failed: %v", err)
+	}
+	result := [][]any{}
+	for rows.Next() {
+		row := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows failed: %v", err)
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	return string(b)
+}
+
+func TestTableFunctions(t *testing.T) {
+	database := setupOrdersDB(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "unnest",
+			query: "SELECT o.id, u.value, u.idx FROM orders o, unnest(o.tags) u ORDER BY o.id, u.idx",
+			want:  `[[1,"new",0],[1,"gift",1]]`,
+		},
+		{
+			name:  "unnest scalar",
+			query: "SELECT value FROM unnest('42')",
+			want:  `[[42]]`,
+		},
+		{
+			name:  "unnest left join",
+			query: "SELECT o.id, u.value FROM orders o LEFT JOIN unnest(o.tags) u ORDER BY o.id",
+			want:  `[[1,"new"],[1,"gift"],[2,null],[3,null]]`,
+		},
+		{
+			name:  "json_table values",
+			query: "SELECT o.id, j.value FROM orders o, json_table(o.detail, '$.items[*].sku') j ORDER BY o.id",
+			want:  `[[1,"A"],[1,"B"],[3,"C"]]`,
+		},
+		{
+			name:  "json_table array without wildcard",
+			query: "SELECT value FROM json_table('{\"a\":{\"b\":[1,[2]]}}', '$.a.b')",
+			want:  `[[1],["[2]"]]`,
+		},
+		{
+			name: "json_table columns",
+			query: `SELECT o.id, j.* FROM orders o,
+				json_table(o.detail, '$.items[*]', 'sku TEXT, qty INT, color TEXT PATH ''$.meta.color''') j
+				ORDER BY o.id, j.sku`,
+			want: `[[1,"A",2,"red"],[1,"B",1,null],[3,"C",null,null]]`,
+		},
+		{
+			name:  "json_table column types",
+			query: `SELECT * FROM json_table('[{"n":"1.5","b":true,"o":{"x":1}}]', '$', 'n REAL, b BOOLEAN, o JSON, raw TEXT PATH ''$.o''')`,
+			want:  `[[1.5,1,"{\"x\":1}","{\"x\":1}"]]`,
+		},
+		{
+			name: "json_table twice with different columns",
+			query: `SELECT a.k, b.v FROM json_table('[{"k":1}]', '$', 'k INT') a,
+				json_table('[{"v":"x"}]', '$', 'v TEXT') b`,
+			want: `[[1,"x"]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, database, tt.query); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableFunctions_ManyDatabases(t *testing.T) {
+	// Every database gets the functions, including while others are open
+	for i := 0; i < 3; i++ {
+		database := setupOrdersDB(t)
+		got := queryJSON(t, database, "SELECT value FROM unnest('[1,2]')")
+		if got != "[[1],[2]]" {
+			t.Errorf("database %d: got %s", i, got)
+		}
+		got = queryJSON(t, database, "SELECT n FROM json_table('[{\"n\":1}]', '$', 'n INT')")
+		if got != "[[1]]" {
+			t.Errorf("database %d: got %s", i, got)
+		}
+	}
+}
+
+func TestTableFunctions_Errors(t *testing.T) {
+	database := setupOrdersDB(t)
+
+	if _, err := database.RewriteQuery("SELECT * FROM json_table('[]', '$', 'x INT PATH 1')"); err == nil {
+		t.Error("expected error for invalid column list")
+	}
+
+	for _, query := range []string{
+		"SELECT * FROM unnest('{not json')",
+		"SELECT * FROM json_table('[]', 'items')",
+	} {
+		rows, err := database.Query(query)
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			_ = rows.Close()
+		}
+		if err == nil {
+			t.Errorf("expected error for %s", query)
+		}
+	}
+}
+
+func TestRewriteQuery_Unchanged(t *testing.T) {
+	database := setupOrdersDB(t)
+
+	for _, query := range []string{
+		"SELECT 1",
+		"SELECT * FROM json_table(detail, '$.items') FROM orders",
+		"SELECT 'json_table(a, b, ''c INT'')'",
+	} {
+		got, err := database.RewriteQuery(query)
+		if err != nil {
+			t.Fatalf("RewriteQuery failed: %v", err)
+		}
+		if got != query {
+			t.Errorf("expected %q unchanged, got %q", query, got)
+		}
+	}
+
+	got, err := database.RewriteQuery("SELECT * FROM JSON_TABLE(x, '$', 'a INT')")
+	if err != nil {
+		t.Fatalf("RewriteQuery failed: %v", err)
+	}
+	if strings.Contains(got, "JSON_TABLE") || !strings.HasSuffix(got, "(x, '$')") {
+		t.Errorf("expected rewritten call, got %q", got)
+	}
+}
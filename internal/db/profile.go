@@ -0,0 +1,149 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+// topValuesLimit is the number of most frequent values reported per column.
+const topValuesLimit = 5
+
+// ColumnProfile summarizes the values of a column. Numeric columns also
+// report their mean and a histogram.
+type ColumnProfile struct {
+	Name      string
+	Type      parser.DataType
+	Count     int64 // number of rows
+	Nulls     int64
+	Distinct  int64 // number of distinct non-NULL values
+	Min, Max  any
+	Mean      *float64     // nil for non-numeric columns or without values
+	TopValues []ValueCount // most frequent non-NULL values, most frequent first
+	Histogram []Bin        // equal-width bins between Min and Max
+}
+
+// ValueCount is a value and the number of rows holding it.
+type ValueCount struct {
+	Value any
+	Count int64
+}
+
+// Bin is a histogram bin counting values in [Low, High), or [Low, High] for the last bin.
+type Bin struct {
+	Low, High float64
+	Count     int64
+}
+
+// Numeric reports whether the column holds numbers.
+func (p *ColumnProfile) Numeric() bool {
+	return p.Type == parser.TypeInteger || p.Type == parser.TypeReal
+}
+
+// ProfileTable computes a profile of each column of a table, with histograms
+// of the given number of bins for numeric columns.
+func (db *DB) ProfileTable(schema, table string, bins int) ([]ColumnProfile, error) {
+	columns, err := db.TableColumns(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	from := quoteIdent(schema) + "." + quoteIdent(table)
+	profiles := make([]ColumnProfile, len(columns))
+	for i, col := range columns {
+		p := &profiles[i]
+		p.Name, p.Type = col.Name, col.Type
+		if err := db.profileColumn(p, from, bins); err != nil {
+			return nil, fmt.Errorf("failed to profile %s.%s: %w", table, col.Name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// profileColumn fills in the statistics of a column of the table expression from.
+func (db *DB) profileColumn(p *ColumnProfile, from string, bins int) error {
+	c := quoteIdent(p.Name)
+
+	var values int64
+	var mean *float64
+	query := fmt.Sprintf("SELECT count(*), count(%[1]s), count(DISTINCT %[1]s), min(%[1]s), max(%[1]s), avg(%[1]s) FROM %[2]s", c, from)
+	if err := db.QueryRow(query).Scan(&p.Count, &values, &p.Distinct, &p.Min, &p.Max, &mean); err != nil {
+		return err
+	}
+	p.Nulls = p.Count - values
+	if p.Numeric() {
+		p.Mean = mean
+	}
+
+	query = fmt.Sprintf("SELECT %[1]s, count(*) AS n FROM %[2]s WHERE %[1]s IS NOT NULL GROUP BY 1 ORDER BY n DESC, 1 LIMIT %[3]d", c, from, topValuesLimit)
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var vc ValueCount
+		if err := rows.Scan(&vc.Value, &vc.Count); err != nil {
+			return err
+		}
+		p.TopValues = append(p.TopValues, vc)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if p.Type == parser.TypeBoolean {
+		p.Min, p.Max = boolValue(p.Min), boolValue(p.Max)
+		for i := range p.TopValues {
+			p.TopValues[i].Value = boolValue(p.TopValues[i].Value)
+		}
+	}
+
+	lo, okLo := toFloat(p.Min)
+	hi, okHi := toFloat(p.Max)
+	if p.Numeric() && okLo && okHi && bins > 0 {
+		p.Histogram, err = db.histogram(c, from, lo, hi, bins)
+	}
+	return err
+}
+
+// histogram counts the values of column c in equal-width bins between lo and hi.
+func (db *DB) histogram(c, from string, lo, hi float64, bins int) ([]Bin, error) {
+	width := (hi - lo) / float64(bins)
+	if width == 0 {
+		bins, width = 1, 1
+	}
+
+	hist := make([]Bin, bins)
+	for i := range hist {
+		hist[i].Low = lo + float64(i)*width
+		hist[i].High = lo + float64(i+1)*width
+	}
+	hist[bins-1].High = hi
+
+	query := fmt.Sprintf("SELECT min(CAST((%[1]s - ?) / ? AS INTEGER), ?), count(*) FROM %[2]s WHERE %[1]s IS NOT NULL GROUP BY 1", c, from)
+	rows, err := db.Query(query, lo, width, bins-1)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var bin int
+		var count int64
+		if err := rows.Scan(&bin, &count); err != nil {
+			return nil, err
+		}
+		if bin >= 0 && bin < bins {
+			hist[bin].Count += count
+		}
+	}
+	return hist, rows.Err()
+}
+
+// boolValue converts an integer stored for a boolean column to a bool.
+func boolValue(v any) any {
+	if n, ok := v.(int64); ok {
+		return n != 0
+	}
+	return v
+}
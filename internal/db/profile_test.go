@@ -0,0 +1,108 @@
+package db_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestDB_ProfileTable(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	data := &parser.ParsedData{
+		Columns: []parser.Column{
+			{Name: "amount", Type: parser.TypeReal},
+			{Name: "region", Type: parser.TypeText},
+			{Name: "paid", Type: parser.TypeBoolean},
+		},
+		Rows: [][]any{
+			{0.0, "east", true},
+			{1.0, "west", true},
+			{2.0, "east", false},
+			{10.0, "east", nil},
+			{nil, "north", true},
+		},
+	}
+	if err := database.LoadData("sales", data); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+
+	profiles, err := database.ProfileTable("main", "sales", 2)
+	if err != nil {
+		t.Fatalf("ProfileTable failed: %v", err)
+	}
+	if len(profiles) != 3 {
+		t.Fatalf("expected 3 profiles, got %d", len(profiles))
+	}
+
+	t.Run("numeric", func(t *testing.T) {
+		p := profiles[0]
+		if !p.Numeric() || p.Count != 5 || p.Nulls != 1 || p.Distinct != 4 {
+			t.Errorf("unexpected counts: %+v", p)
+		}
+		if p.Min != 0.0 || p.Max != 10.0 {
+			t.Errorf("got min %v, max %v", p.Min, p.Max)
+		}
+		if p.Mean == nil || *p.Mean != 3.25 {
+			t.Errorf("got mean %v, want 3.25", p.Mean)
+		}
+		want := []db.Bin{{Low: 0, High: 5, Count: 3}, {Low: 5, High: 10, Count: 1}}
+		if !reflect.DeepEqual(p.Histogram, want) {
+			t.Errorf("got histogram %+v, want %+v", p.Histogram, want)
+		}
+	})
+
+	t.Run("categorical", func(t *testing.T) {
+		p := profiles[1]
+		if p.Numeric() || p.Mean != nil || p.Histogram != nil {
+			t.Errorf("expected no numeric statistics: %+v", p)
+		}
+		want := []db.ValueCount{{Value: "east", Count: 3}, {Value: "north", Count: 1}, {Value: "west", Count: 1}}
+		if !reflect.DeepEqual(p.TopValues, want) {
+			t.Errorf("got top values %+v, want %+v", p.TopValues, want)
+		}
+	})
+
+	t.Run("boolean", func(t *testing.T) {
+		p := profiles[2]
+		if p.Min != false || p.Max != true || p.Nulls != 1 {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+		want := []db.ValueCount{{Value: true, Count: 3}, {Value: false, Count: 1}}
+		if !reflect.DeepEqual(p.TopValues, want) {
+			t.Errorf("got top values %+v, want %+v", p.TopValues, want)
+		}
+	})
+}
+
+func TestDB_ProfileTable_SingleValue(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	data := &parser.ParsedData{
+		Columns: []parser.Column{{Name: "n", Type: parser.TypeInteger}},
+		Rows:    [][]any{{int64(7)}, {int64(7)}},
+	}
+	if err := database.LoadData("t", data); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+
+	profiles, err := database.ProfileTable("main", "t", 10)
+	if err != nil {
+		t.Fatalf("ProfileTable failed: %v", err)
+	}
+	want := []db.Bin{{Low: 7, High: 7, Count: 2}}
+	if !reflect.DeepEqual(profiles[0].Histogram, want) {
+		t.Errorf("got histogram %+v, want %+v", profiles[0].Histogram, want)
+	}
+}
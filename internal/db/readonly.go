@@ -0,0 +1,64 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+// errWritesInQueryOnly is returned for statements that query-only mode does
+// not stop from changing the database or files.
+var errWritesInQueryOnly = errors.New("not allowed in read-only mode")
+
+// SetReadOnly turns query-only mode on or off. While it is on, statements that
+// would modify a database (INSERT, CREATE, DROP, ...) fail with an error for
+// which IsReadOnlyError reports true. Statements must also pass CheckReadOnly,
+// as some of them escape query-only mode.
+func (db *DB) SetReadOnly(on bool) error {
+	if _, err := db.Exec(QueryOnlyPragma(on)); err != nil {
+		return fmt.Errorf("failed to set read-only mode: %w", err)
+	}
+	return nil
+}
+
+// QueryOnlyPragma returns the statement that turns query-only mode on or off
+// for a single connection.
+func QueryOnlyPragma(on bool) string {
+	if on {
+		return "PRAGMA query_only = ON"
+	}
+	return "PRAGMA query_only = OFF"
+}
+
+// CheckReadOnly returns an error for which IsReadOnlyError reports true if
+// the SQL text holds a statement that writes despite query-only mode: a PRAGMA
+// that sets a value, which can turn query-only mode off; ATTACH and DETACH,
+// which create database files; and VACUUM, which can write a file with INTO.
+func CheckReadOnly(query string) error {
+	for _, stmt := range sqllex.Split(query) {
+		tokens := sqllex.Tokenize(stmt)
+		first := tokens[0]
+		switch {
+		case first.Is("attach"), first.Is("detach"), first.Is("vacuum"):
+			return fmt.Errorf("%s is %w", strings.ToUpper(first.Text), errWritesInQueryOnly)
+		case sqllex.IsPragmaAssignment(tokens):
+			return fmt.Errorf("PRAGMA assignment is %w", errWritesInQueryOnly)
+		}
+	}
+	return nil
+}
+
+// IsReadOnlyError reports whether err was caused by a statement writing to a
+// database in query-only mode or attached read-only, or refused by CheckReadOnly.
+func IsReadOnlyError(err error) bool {
+	if errors.Is(err, errWritesInQueryOnly) {
+		return true
+	}
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_READONLY
+}
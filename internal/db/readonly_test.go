@@ -0,0 +1,77 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestDB_SetReadOnly(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	if _, err := database.Exec("CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := database.SetReadOnly(true); err != nil {
+		t.Fatalf("SetReadOnly failed: %v", err)
+	}
+
+	writes := []string{
+		"INSERT INTO t VALUES (1)",
+		"DELETE FROM t",
+		"CREATE TABLE u (id INTEGER)",
+		"DROP TABLE t",
+	}
+	for _, stmt := range writes {
+		_, err := database.Exec(stmt)
+		if !db.IsReadOnlyError(err) {
+			t.Errorf("%s: expected read-only error, got %v", stmt, err)
+		}
+	}
+
+	var n int
+	if err := database.QueryRow("SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Errorf("expected reads to succeed: %v", err)
+	}
+	if _, err := database.Exec("SELECT * FROM nope"); db.IsReadOnlyError(err) {
+		t.Errorf("expected other errors not to be read-only errors: %v", err)
+	}
+
+	if err := database.SetReadOnly(false); err != nil {
+		t.Fatalf("SetReadOnly failed: %v", err)
+	}
+	if _, err := database.Exec("INSERT INTO t VALUES (1)"); err != nil {
+		t.Errorf("expected writes after leaving read-only mode: %v", err)
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		ok    bool
+	}{
+		{"SELECT * FROM t", true},
+		{"PRAGMA table_info(t)", true},
+		{"PRAGMA user_version", true},
+		{"DELETE FROM t", true}, // refused by query-only mode
+		{"PRAGMA query_only = OFF", false},
+		{"SELECT 1; PRAGMA main.query_only(0)", false},
+		{"ATTACH 'a.db' AS a", false},
+		{"DETACH a", false},
+		{"VACUUM INTO 'b.db'", false},
+	}
+	for _, tt := range tests {
+		err := db.CheckReadOnly(tt.query)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.query, err)
+		}
+		if !tt.ok && !db.IsReadOnlyError(err) {
+			t.Errorf("%s: expected read-only error, got %v", tt.query, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// init registers the regular expression functions.
+// regexp(pattern, text) backs the REGEXP operator.
+func init() {
+	MustRegisterFunction(&Function{
+		Name: "regexp", Args: "pattern, text", MinArgs: 2, MaxArgs: 2,
+		Description: "1 if text matches pattern; used by the REGEXP operator",
+		Scalar:      regexpMatch,
+	})
+	MustRegisterFunction(&Function{
+		Name: "regexp_like", Args: "text, pattern[, flags]", MinArgs: 2, MaxArgs: 3,
+		Description: "1 if text matches pattern",
+		Scalar:      regexpLike,
+	})
+	MustRegisterFunction(&Function{
+		Name: "regexp_extract", Args: "text, pattern[, group]", MinArgs: 2, MaxArgs: 3,
+		Description: "First match of pattern, or its capture group by index or name",
+		Scalar:      regexpExtract,
+	})
+	MustRegisterFunction(&Function{
+		Name: "regexp_replace", Args: "text, pattern, replacement[, flags]", MinArgs: 3, MaxArgs: 4,
+		Description: "Replace the first match of pattern (all with flag g)",
+		Scalar:      regexpReplace,
+	})
+}
+
+// regexpMatch implements `text REGEXP pattern`, which SQLite calls as regexp(pattern, text).
+func regexpMatch(args []driver.Value) (driver.Value, error) {
+	pattern, ok1 := toText(args[0])
+	text, ok2 := toText(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	re, err := compileRegexp(pattern, "")
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(text), nil
+}
+
+// regexpLike implements regexp_like(text, pattern[, flags]).
+func regexpLike(args []driver.Value) (driver.Value, error) {
+	text, re, ok, err := regexpArgs(args, 2)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return re.MatchString(text), nil
+}
+
+// regexpExtract implements regexp_extract(text, pattern[, group]).
+// It returns the given capture group (default: whole match) of the first match, or NULL.
+func regexpExtract(args []driver.Value) (driver.Value, error) {
+	text, re, ok, err := regexpArgs(args, -1)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	group := 0
+	if len(args) == 3 {
+		g, ok := toText(args[2])
+		if !ok {
+			return nil, nil
+		}
+		if group, err = strconv.Atoi(g); err != nil {
+			if group = re.SubexpIndex(g); group < 0 {
+				return nil, fmt.Errorf("regexp_extract: unknown group %q", g)
+			}
+		}
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return nil, fmt.Errorf("regexp_extract: group %d out of range", group)
+	}
+
+	match := re.FindStringSubmatchIndex(text)
+	if match == nil || match[2*group] < 0 {
+		return nil, nil
+	}
+	return text[match[2*group]:match[2*group+1]], nil
+}
+
+// regexpReplace implements regexp_replace(text, pattern, replacement[, flags]).
+// Only the first match is replaced unless flags contain "g". The replacement
+// may reference capture groups as $1 or ${name}.
+func regexpReplace(args []driver.Value) (driver.Value, error) {
+	text, re, ok, err := regexpArgs(args, 3)
+	if !ok || err != nil {
+		return nil, err
+	}
+	repl, ok := toText(args[2])
+	if !ok {
+		return nil, nil
+	}
+
+	if len(args) == 4 {
+		flags, _ := toText(args[3])
+		if strings.Contains(flags, "g") {
+			return re.ReplaceAllString(text, repl), nil
+		}
+	}
+
+	match := re.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text, nil
+	}
+	var b []byte
+	b = append(b, text[:match[0]]...)
+	b = re.ExpandString(b, repl, text, match)
+	b = append(b, text[match[1]:]...)
+	return string(b), nil
+}
+
+// regexpArgs extracts the text and compiled pattern from the first two arguments,
+// using the argument at flagsIdx (if present) as flags. ok is false if any is NULL.
+func regexpArgs(args []driver.Value, flagsIdx int) (text string, re *regexp.Regexp, ok bool, err error) {
+	text, ok1 := toText(args[0])
+	pattern, ok2 := toText(args[1])
+	if !ok1 || !ok2 {
+		return "", nil, false, nil
+	}
+
+	var flags string
+	if flagsIdx >= 0 && flagsIdx < len(args) {
+		flags, _ = toText(args[flagsIdx])
+	}
+	re, err = compileRegexp(pattern, flags)
+	return text, re, err == nil, err
+}
+
+// maxCachedRegexps bounds the number of compiled patterns kept in memory.
+const maxCachedRegexps = 256
+
+var (
+	regexpCacheMu sync.Mutex
+	regexpCache   = make(map[string]*regexp.Regexp)
+)
+
+// compileRegexp compiles a pattern with flags, reusing previously compiled patterns.
+// Supported flags: i (case-insensitive), m (multi-line), s (dot matches newline)
+// and g (global replace, ignored here).
+func compileRegexp(pattern, flags string) (*regexp.Regexp, error) {
+	var prefix string
+	for _, f := range flags {
+		switch f {
+		case 'i', 'm', 's':
+			if !strings.ContainsRune(prefix, f) {
+				prefix += string(f)
+			}
+		case 'g':
+		default:
+			return nil, fmt.Errorf("unsupported regexp flag %q", f)
+		}
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+
+	regexpCacheMu.Lock()
+	defer regexpCacheMu.Unlock()
+
+	if re, ok := regexpCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache) >= maxCachedRegexps {
+		clear(regexpCache)
+	}
+	regexpCache[pattern] = re
+	return re, nil
+}
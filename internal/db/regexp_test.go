@@ -0,0 +1,70 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestRegexpFunctions(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	tests := []struct {
+		query string
+		want  any
+	}{
+		{"SELECT 'ERROR: disk full' REGEXP '^ERROR'", int64(1)},
+		{"SELECT 'info' REGEXP '^ERROR'", int64(0)},
+		{"SELECT NULL REGEXP 'x'", nil},
+		{"SELECT regexp_like('Hello', '^hello')", int64(0)},
+		{"SELECT regexp_like('Hello', '^hello', 'i')", int64(1)},
+		{"SELECT regexp_extract('user=alice id=42', 'id=(\\d+)', 1)", "42"},
+		{"SELECT regexp_extract('user=alice', 'user=\\w+')", "user=alice"},
+		{"SELECT regexp_extract('user=alice', 'user=(?P<name>\\w+)', 'name')", "alice"},
+		{"SELECT regexp_extract('abc', '\\d+')", nil},
+		{"SELECT regexp_replace('a1b2', '\\d', '#')", "a#b2"},
+		{"SELECT regexp_replace('a1b2', '\\d', '#', 'g')", "a#b#"},
+		{"SELECT regexp_replace('John Smith', '(\\w+) (\\w+)', '$2, $1')", "Smith, John"},
+		{"SELECT regexp_replace('AbA', 'a', '-', 'gi')", "-b-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got any
+			if err := database.QueryRow(tt.query).Scan(&got); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexpFunctions_Errors(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	queries := []string{
+		"SELECT 'a' REGEXP '('",
+		"SELECT regexp_like('a')",
+		"SELECT regexp_like('a', 'a', 'x')",
+		"SELECT regexp_extract('a', 'a', 2)",
+		"SELECT regexp_replace('a', 'a')",
+	}
+
+	for _, query := range queries {
+		var got any
+		if err := database.QueryRow(query).Scan(&got); err == nil {
+			t.Errorf("expected error for %s", query)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveTo writes the main database, including tables and views created during
+// the session, to a standalone SQLite file at path. An existing file is replaced.
+func (db *DB) SaveTo(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	_ = tmp.Close()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	// VACUUM INTO requires the target to be absent or empty
+	if _, err := db.Exec("VACUUM main INTO ?", tmpPath); err != nil {
+		return fmt.Errorf("failed to save database to %s: %w", path, err)
+	}
+	if err := dropInternalTables(tmpPath); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// dropInternalTables removes qo's bookkeeping tables from a saved database.
+func dropInternalTables(path string) error {
+	saved, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = saved.Close() }()
+
+	for _, table := range []string{sourcesTable, columnsTable} {
+		if _, err := saved.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", table)); err != nil {
+			return fmt.Errorf("failed to clean up %s: %w", path, err)
+		}
+	}
+	return nil
+}
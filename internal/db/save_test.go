@@ -0,0 +1,72 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestDB_SaveTo(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	data := &parser.ParsedData{
+		Columns: []parser.Column{{Name: "id", Type: parser.TypeInteger}},
+		Rows:    [][]any{{int64(1)}, {int64(2)}},
+	}
+	if err := database.LoadData("users", data); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+	if _, err := database.Exec("CREATE VIEW first_user AS SELECT * FROM users WHERE id = 1"); err != nil {
+		t.Fatalf("failed to create view: %v", err)
+	}
+	if err := database.RecordSource("users", &db.SourceInfo{Path: "/data/users.json"}); err != nil {
+		t.Fatalf("RecordSource failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+	// Saving twice replaces the previous file
+	for range 2 {
+		if err := database.SaveTo(path); err != nil {
+			t.Fatalf("SaveTo failed: %v", err)
+		}
+	}
+
+	saved, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open saved db: %v", err)
+	}
+	testutil.CloseDB(t, saved)
+
+	tables, err := saved.Tables("main")
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if !slices.Equal(tables, []string{"first_user", "users"}) {
+		t.Errorf("Tables() = %v, want [first_user users]", tables)
+	}
+
+	var internal int
+	if err := saved.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name IN ('_qo_sources', '_qo_columns')").Scan(&internal); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if internal != 0 {
+		t.Error("expected internal tables to be removed from the saved database")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the saved file to remain, got %d entries", len(entries))
+	}
+}
@@ -5,32 +5,94 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/stats"
 )
 
+// memoryPath is the SQLite path for an in-memory database.
+const memoryPath = ":memory:"
+
 // DB wraps sql.DB with additional functionality.
 type DB struct {
 	*sql.DB
+	path string
+
+	loadedColumns map[string][]parser.Column // columns of tables loaded or reused in this session, as inferred
+	stats         *stats.Stats               // records rows inserted by LoadData; nil to disable
 }
 
 // New creates a new in-memory SQLite database.
 func New() (*DB, error) {
-	db, err := sql.Open("sqlite", ":memory:")
+	return Open(memoryPath)
+}
+
+// Open opens a SQLite database backed by the file at path, creating it if needed.
+// An empty path or ":memory:" opens an in-memory database.
+func Open(path string) (*DB, error) {
+	if path == "" {
+		path = memoryPath
+	}
+	if err := registerModules(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return &DB{DB: db}, nil
+	// Use a single connection: in-memory data and attached databases are per-connection
+	db.SetMaxOpenConns(1)
+
+	d := &DB{DB: db, path: path}
+	if err := d.initTableFunctions(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Persistent reports whether the database is backed by a file.
+func (db *DB) Persistent() bool {
+	return db.path != memoryPath
+}
+
+// SetStats makes LoadData record the rows it inserts and the time taken in s.
+func (db *DB) SetStats(s *stats.Stats) {
+	db.stats = s
 }
 
 // LoadData loads parsed data into a table.
 func (db *DB) LoadData(tableName string, data *parser.ParsedData) error {
+	start := time.Now()
 	if err := db.createTable(tableName, data.Columns); err != nil {
 		return err
 	}
-	return db.insertRows(tableName, data.Columns, data.Rows)
+	db.setLoadedColumns(tableName, data.Columns)
+	if err := db.insertRows(tableName, data.Columns, data.Rows); err != nil {
+		return err
+	}
+	db.stats.AddInsert(tableName, int64(len(data.Rows)), time.Since(start))
+	return nil
+}
+
+// setLoadedColumns records the inferred columns of a table loaded or reused
+// in this session.
+func (db *DB) setLoadedColumns(tableName string, columns []parser.Column) {
+	if db.loadedColumns == nil {
+		db.loadedColumns = make(map[string][]parser.Column)
+	}
+	db.loadedColumns[tableName] = columns
+}
+
+// ReplaceData loads parsed data into a table, dropping any existing table with the same name.
+func (db *DB) ReplaceData(tableName string, data *parser.ParsedData) error {
+	if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", tableName)); err != nil {
+		return fmt.Errorf("failed to drop table %s: %w", tableName, err)
+	}
+	return db.LoadData(tableName, data)
 }
 
 // createTable creates a table with the given columns.
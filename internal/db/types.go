@@ -0,0 +1,184 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+// ResultTypes returns the type each column of a query result had in the data
+// it was loaded from, so that output can restore booleans and JSON values
+// that SQLite stores as integers and text.
+//
+// SQLite only reports the declared type of result columns that refer to a
+// table column, so a column is matched by name (or the name it is aliased
+// from) and declared type against the tables loaded in this session that the
+// query mentions, unqualified or as main.table; tables of attached databases
+// are not loaded. Expressions that call a function returning JSON, e.g.
+// json_object(...), report TypeJSON. Other expressions, and columns that match
+// no loaded column or several with different types, report TypeNull, meaning
+// the type is unknown.
+func (db *DB) ResultTypes(query string, columns []*sql.ColumnType) []parser.DataType {
+	var tables [][]parser.Column
+	seen := make(map[string]bool)
+	aliases := make(map[string]string) // alias of a column, e.g. "b" in "t.a AS b", to its name
+	tokens := sqllex.Tokenize(query)
+	for i, tok := range tokens {
+		if !tok.IsIdent() {
+			continue
+		}
+		if i+2 < len(tokens) && tokens[i+1].Is("as") && tokens[i+2].IsIdent() {
+			aliases[strings.ToLower(tokens[i+2].Ident())] = tok.Ident()
+		}
+		// Loaded tables live in main; ref.t names a table of an attached database
+		if i >= 2 && tokens[i-1].Is(".") && !strings.EqualFold(tokens[i-2].Ident(), "main") {
+			continue
+		}
+		name := strings.ToLower(tok.Ident())
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		for table, cols := range db.loadedColumns {
+			if strings.EqualFold(table, name) {
+				tables = append(tables, cols)
+			}
+		}
+	}
+
+	jsonColumns := jsonResultColumns(query, tokens)
+	types := make([]parser.DataType, len(columns))
+	for i, col := range columns {
+		name := col.Name()
+		if col.DatabaseTypeName() == "" && jsonColumns[strings.ToLower(name)] {
+			types[i] = parser.TypeJSON
+			continue
+		}
+		if orig, ok := aliases[strings.ToLower(name)]; ok {
+			name = orig
+		}
+		types[i] = sourceType(tables, name, col.DatabaseTypeName())
+	}
+	return types
+}
+
+// jsonFunctions lists the functions whose result is JSON text.
+var jsonFunctions = map[string]bool{
+	"json":              true,
+	"json_array":        true,
+	"json_extract":      true,
+	"json_group_array":  true,
+	"json_group_object": true,
+	"json_insert":       true,
+	"json_object":       true,
+	"json_patch":        true,
+	"json_remove":       true,
+	"json_replace":      true,
+	"json_set":          true,
+	"url_parse":         true, // with no part argument
+}
+
+// jsonResultColumns returns the lowercased names of the result columns of
+// the SELECTs in query whose expression is a call of one of jsonFunctions.
+// SQLite names such a column by its alias, or else by the text of the call.
+func jsonResultColumns(query string, tokens []sqllex.Token) map[string]bool {
+	names := make(map[string]bool)
+	for i, tok := range tokens {
+		if !tok.Is("select") {
+			continue
+		}
+		start := i + 1
+		if start < len(tokens) && (tokens[start].Is("distinct") || tokens[start].Is("all")) {
+			start++
+		}
+		depth := 0
+	items:
+		for j := start; j <= len(tokens); j++ {
+			if j < len(tokens) {
+				switch t := tokens[j]; {
+				case t.Is("("):
+					depth++
+					continue
+				case t.Is(")"):
+					if depth > 0 {
+						depth--
+						continue
+					}
+				case depth > 0:
+					continue
+				case !t.Is(",") && !t.Is(";") && !t.Is("from") && !t.Is("where") && !t.Is("group") &&
+					!t.Is("order") && !t.Is("limit") && !t.Is("union") && !t.Is("except") && !t.Is("intersect"):
+					continue
+				}
+			}
+			if name, ok := jsonCallName(query, tokens[start:j]); ok {
+				names[strings.ToLower(name)] = true
+			}
+			if j == len(tokens) || !tokens[j].Is(",") {
+				break items
+			}
+			start = j + 1
+		}
+	}
+	return names
+}
+
+// jsonCallName returns the result column name of a select-list item that is a
+// call of one of jsonFunctions, optionally aliased.
+func jsonCallName(query string, item []sqllex.Token) (string, bool) {
+	if len(item) < 3 || item[0].Kind != sqllex.Word || !jsonFunctions[strings.ToLower(item[0].Text)] || !item[1].Is("(") {
+		return "", false
+	}
+	end, depth, args := -1, 0, 1
+	for k := 1; k < len(item) && end < 0; k++ {
+		switch {
+		case item[k].Is("("):
+			depth++
+		case item[k].Is(")"):
+			depth--
+			if depth == 0 {
+				end = k
+			}
+		case item[k].Is(",") && depth == 1:
+			args++
+		}
+	}
+	if end < 0 || (strings.EqualFold(item[0].Text, "url_parse") && args != 1) {
+		return "", false
+	}
+
+	switch rest := item[end+1:]; {
+	case len(rest) == 0:
+		return query[item[0].Pos:item[end].End], true
+	case len(rest) == 1 && rest[0].IsIdent():
+		return rest[0].Ident(), true
+	case len(rest) == 2 && rest[0].Is("as") && rest[1].IsIdent():
+		return rest[1].Ident(), true
+	default:
+		return "", false
+	}
+}
+
+// sourceType returns the type of the column with the given name and declared
+// type in tables, or TypeNull if there is no such column or it is ambiguous.
+func sourceType(tables [][]parser.Column, name, declType string) parser.DataType {
+	if declType == "" {
+		return parser.TypeNull
+	}
+
+	typ, found := parser.TypeNull, false
+	for _, cols := range tables {
+		for _, col := range cols {
+			if !strings.EqualFold(col.Name, name) || !strings.EqualFold(col.Type.String(), declType) {
+				continue
+			}
+			if found && col.Type != typ {
+				return parser.TypeNull
+			}
+			typ, found = col.Type, true
+		}
+	}
+	return typ
+}
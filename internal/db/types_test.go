@@ -0,0 +1,110 @@
+package db_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+func TestDB_ResultTypes(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	load := func(table string, columns ...parser.Column) {
+		t.Helper()
+		if err := database.LoadData(table, &parser.ParsedData{Columns: columns}); err != nil {
+			t.Fatalf("LoadData failed: %v", err)
+		}
+	}
+	load("users",
+		parser.Column{Name: "id", Type: parser.TypeInteger},
+		parser.Column{Name: "active", Type: parser.TypeBoolean},
+		parser.Column{Name: "tags", Type: parser.TypeJSON},
+		parser.Column{Name: "note", Type: parser.TypeText},
+	)
+	load("orders",
+		parser.Column{Name: "id", Type: parser.TypeInteger},
+		parser.Column{Name: "active", Type: parser.TypeInteger},
+	)
+	// An attached table named like a loaded one, with the same declared types
+	if _, err := database.Exec("ATTACH ':memory:' AS ref; CREATE TABLE ref.users (active INTEGER, tags TEXT)"); err != nil {
+		t.Fatalf("failed to attach: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []parser.DataType
+	}{
+		{
+			name:  "table columns",
+			query: "SELECT * FROM users",
+			want:  []parser.DataType{parser.TypeInteger, parser.TypeBoolean, parser.TypeJSON, parser.TypeText},
+		},
+		{
+			name:  "aliased columns",
+			query: "SELECT u.active AS enabled, tags AS labels FROM users u",
+			want:  []parser.DataType{parser.TypeBoolean, parser.TypeJSON},
+		},
+		{
+			name:  "expressions",
+			query: "SELECT NOT active, lower(note) AS tags FROM users",
+			want:  []parser.DataType{parser.TypeNull, parser.TypeNull},
+		},
+		{
+			name:  "JSON functions",
+			query: "SELECT json_array(id), json_object('a', json_array(1, 2)) AS obj, url_parse(note) u, url_parse(note, 'host') h FROM users",
+			want:  []parser.DataType{parser.TypeJSON, parser.TypeJSON, parser.TypeJSON, parser.TypeNull},
+		},
+		{
+			name:  "JSON function in a subquery",
+			query: "SELECT x, n FROM (SELECT json_group_array(id) AS x, count(*) AS n FROM users)",
+			want:  []parser.DataType{parser.TypeJSON, parser.TypeNull},
+		},
+		{
+			name:  "ambiguous column",
+			query: "SELECT u.active, u.id FROM users u JOIN orders o ON o.id = u.id",
+			want:  []parser.DataType{parser.TypeNull, parser.TypeInteger},
+		},
+		{
+			name:  "main schema",
+			query: "SELECT active FROM main.users",
+			want:  []parser.DataType{parser.TypeBoolean},
+		},
+		{
+			name:  "attached table with a loaded name",
+			query: "SELECT active, tags FROM ref.users",
+			want:  []parser.DataType{parser.TypeNull, parser.TypeNull},
+		},
+		{
+			name:  "table not loaded",
+			query: "SELECT name FROM sqlite_master",
+			want:  []parser.DataType{parser.TypeNull},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := database.Query(tt.query)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			defer func() { _ = rows.Close() }()
+			columns, err := rows.ColumnTypes()
+			if err != nil {
+				t.Fatalf("ColumnTypes failed: %v", err)
+			}
+
+			got := database.ResultTypes(tt.query, columns)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResultTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package input
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kiki-ki/go-qo/internal/db"
+)
+
+// staleFiles returns the files that need to be (re)loaded, restoring the
+// column types of the tables that are reused.
+// A file is fresh when its recorded size and modification time are unchanged,
+// or when only the modification time changed but the content hash did not.
+func (l *Loader) staleFiles(filePaths []string) ([]string, []error) {
+	var stale []string
+	var errs []error
+
+	for _, path := range filePaths {
+		fresh, err := l.isFresh(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !fresh {
+			stale = append(stale, path)
+			continue
+		}
+		if err := l.db.RestoreColumns(db.TableNameFromPath(path)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return stale, errs
+}
+
+// isFresh reports whether the table loaded from path is up to date.
+func (l *Loader) isFresh(path string) (bool, error) {
+	current, err := l.sourceInfo(path, false)
+	if err != nil {
+		// Let the parser report unreadable files
+		return false, nil
+	}
+
+	tableName := db.TableNameFromPath(path)
+	recorded, err := l.db.LoadedSource(tableName)
+	if err != nil {
+		return false, err
+	}
+	if recorded == nil || !recorded.SameFile(current) {
+		return false, nil
+	}
+	if recorded.ModTime == current.ModTime {
+		return true, nil
+	}
+
+	current.Hash, err = hashFile(path)
+	if err != nil || current.Hash != recorded.Hash {
+		return false, nil
+	}
+
+	// Content is unchanged (e.g. the file was touched); remember the new mtime
+	return true, l.db.RecordSource(tableName, current)
+}
+
+// sourceInfo describes the current state of a file.
+// The content hash is computed only when withHash is true.
+func (l *Loader) sourceInfo(path string, withHash bool) (*db.SourceInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	info := &db.SourceInfo{
+		Path:    absPath,
+		Options: fmt.Sprintf("format=%s,no-header=%t", l.format, l.options.NoHeader),
+		Size:    stat.Size(),
+		ModTime: stat.ModTime().UnixNano(),
+	}
+
+	if withHash {
+		if info.Hash, err = hashFile(absPath); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 of a file's content.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
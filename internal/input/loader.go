@@ -1,17 +1,25 @@
 package input
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"time"
 
 	"github.com/kiki-ki/go-qo/internal/db"
 	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/stats"
 )
 
 // LoaderOptions configures loader behavior.
 type LoaderOptions struct {
 	NoHeader bool // CSV: treat first row as data, not header
+	Jobs     int  // Number of files parsed concurrently (default: number of CPUs)
+	Cache    bool // Skip files that are unchanged since they were last loaded into the database
+
+	Stats *stats.Stats // records parse times and bytes read; nil to disable
 }
 
 // Loader handles loading data into the database.
@@ -49,6 +57,7 @@ func (l *Loader) LoadStdin(tableName string) error {
 
 // LoadReader loads data from an io.Reader into the database.
 func (l *Loader) LoadReader(r io.Reader, tableName string) error {
+	start := time.Now()
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return fmt.Errorf("failed to read input: %w", err)
@@ -58,8 +67,9 @@ func (l *Loader) LoadReader(r io.Reader, tableName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse input: %w", err)
 	}
+	l.options.Stats.AddParse(time.Since(start), int64(len(data)))
 
-	if err := l.db.LoadData(tableName, parsed); err != nil {
+	if err := l.store(tableName, parsed); err != nil {
 		return fmt.Errorf("failed to load data: %w", err)
 	}
 
@@ -67,23 +77,102 @@ func (l *Loader) LoadReader(r io.Reader, tableName string) error {
 }
 
 // LoadFiles loads data from files into the database.
+// Files are parsed concurrently by up to Jobs workers, while inserts are
+// serialized in argument order. A worker slot is released only after its
+// result has been inserted, so at most Jobs parsed files are held in memory.
+// Per-file errors are collected and returned together.
+// With Cache enabled, files whose recorded source is unchanged are skipped.
 func (l *Loader) LoadFiles(filePaths []string) error {
-	for _, path := range filePaths {
-		parsed, err := l.parseFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", path, err)
-		}
+	var errs []error
+	if l.options.Cache {
+		filePaths, errs = l.staleFiles(filePaths)
+	}
 
-		tableName := db.TableNameFromPath(path)
+	results := make([]chan parseResult, len(filePaths))
+	for i := range results {
+		results[i] = make(chan parseResult, 1)
+	}
 
-		if err := l.db.LoadData(tableName, parsed); err != nil {
-			return fmt.Errorf("failed to load table %s: %w", tableName, err)
+	sem := make(chan struct{}, l.jobs())
+	go func() {
+		for i, path := range filePaths {
+			sem <- struct{}{}
+			go func() {
+				results[i] <- l.parseSource(path)
+			}()
 		}
+	}()
+
+	for i, path := range filePaths {
+		if err := l.loadParsed(path, <-results[i]); err != nil {
+			errs = append(errs, err)
+		}
+		<-sem
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadParsed inserts a parsed file into the table derived from its path.
+func (l *Loader) loadParsed(path string, res parseResult) error {
+	if res.err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, res.err)
 	}
 
+	tableName := db.TableNameFromPath(path)
+
+	if err := l.store(tableName, res.data); err != nil {
+		return fmt.Errorf("failed to load table %s: %w", tableName, err)
+	}
+	if res.source != nil {
+		return l.db.RecordSource(tableName, res.source)
+	}
 	return nil
 }
 
+// store inserts parsed data into a table.
+// File-backed databases may already hold the table from a previous run, so it is replaced.
+func (l *Loader) store(tableName string, data *parser.ParsedData) error {
+	if l.db.Persistent() {
+		return l.db.ReplaceData(tableName, data)
+	}
+	return l.db.LoadData(tableName, data)
+}
+
+// parseResult holds the outcome of parsing a single file.
+type parseResult struct {
+	data   *parser.ParsedData
+	source *db.SourceInfo // set when caching is enabled
+	err    error
+}
+
+// parseSource parses a file and, when caching is enabled, fingerprints it.
+func (l *Loader) parseSource(path string) parseResult {
+	start := time.Now()
+	parsed, err := l.parseFile(path)
+	if err == nil && l.options.Stats != nil {
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		l.options.Stats.AddParse(time.Since(start), size)
+	}
+	if err != nil || !l.options.Cache {
+		return parseResult{data: parsed, err: err}
+	}
+
+	source, err := l.sourceInfo(path, true)
+	return parseResult{data: parsed, source: source, err: err}
+}
+
+// jobs returns the number of concurrent parse workers.
+func (l *Loader) jobs() int {
+	if l.options.Jobs > 0 {
+		return l.options.Jobs
+	}
+	return runtime.NumCPU()
+}
+
 // parseBytes parses byte data based on the format.
 func (l *Loader) parseBytes(data []byte) (*parser.ParsedData, error) {
 	switch l.format {
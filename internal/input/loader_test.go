@@ -1,11 +1,17 @@
 package input_test
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kiki-ki/go-qo/internal/db"
 	"github.com/kiki-ki/go-qo/internal/input"
+	"github.com/kiki-ki/go-qo/internal/parser"
+	"github.com/kiki-ki/go-qo/internal/stats"
 	"github.com/kiki-ki/go-qo/internal/testutil"
 )
 
@@ -237,3 +243,220 @@ func TestLoader_LoadFiles_MultipleFiles(t *testing.T) {
 		t.Errorf("expected 2 nested records, got %d", nestedCount)
 	}
 }
+
+func TestLoader_LoadFiles_Jobs(t *testing.T) {
+	paths := []string{
+		testutil.JSONTestdataPath("multiple.json"),
+		testutil.JSONTestdataPath("nested.json"),
+		testutil.JSONTestdataPath("single.json"),
+	}
+
+	for _, jobs := range []int{0, 1, 2, 8} {
+		database, err := db.New()
+		if err != nil {
+			t.Fatalf("failed to create db: %v", err)
+		}
+		testutil.CloseDB(t, database)
+
+		loader := input.NewLoader(database, input.FormatJSON, &input.LoaderOptions{Jobs: jobs})
+		if err := loader.LoadFiles(paths); err != nil {
+			t.Fatalf("LoadFiles with %d jobs failed: %v", jobs, err)
+		}
+
+		for _, table := range []string{"multiple", "nested", "single"} {
+			var count int
+			if err := database.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+				t.Errorf("jobs=%d: query %s failed: %v", jobs, table, err)
+			}
+		}
+	}
+}
+
+func TestLoader_LoadFiles_CollectsErrors(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	paths := []string{
+		testutil.JSONTestdataPath("invalid.json"),
+		testutil.JSONTestdataPath("multiple.json"),
+		"/nonexistent/file.json",
+	}
+	loader := input.NewLoader(database, input.FormatJSON, &input.LoaderOptions{Jobs: 2})
+	err = loader.LoadFiles(paths)
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "invalid.json") || !strings.Contains(msg, "/nonexistent/file.json") {
+		t.Errorf("expected both failing files in error, got: %v", msg)
+	}
+
+	// Valid files are still loaded
+	var count int
+	if err := database.QueryRow("SELECT COUNT(*) FROM multiple").Scan(&count); err != nil {
+		t.Fatalf("query multiple failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 records, got %d", count)
+	}
+}
+
+func TestLoader_LoadFiles_Cache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "users.json")
+	writeFile(t, src, `[{"id": 1}, {"id": 2}]`)
+
+	database, err := db.Open(filepath.Join(dir, "cache.sqlite"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	loader := input.NewLoader(database, input.FormatJSON, &input.LoaderOptions{Cache: true})
+	countRows := func() int {
+		t.Helper()
+		if err := loader.LoadFiles([]string{src}); err != nil {
+			t.Fatalf("LoadFiles failed: %v", err)
+		}
+		var count int
+		if err := database.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		return count
+	}
+
+	if got := countRows(); got != 2 {
+		t.Fatalf("expected 2 rows on first load, got %d", got)
+	}
+
+	// Unchanged source: the table is kept as is
+	if _, err := database.Exec("DELETE FROM users WHERE id = 1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if got := countRows(); got != 1 {
+		t.Errorf("expected cached table with 1 row, got %d", got)
+	}
+
+	// Touched but identical content: still cached
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(src, later, later); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+	if got := countRows(); got != 1 {
+		t.Errorf("expected cached table after touch, got %d rows", got)
+	}
+
+	// Changed content: the table is reloaded
+	writeFile(t, src, `[{"id": 1}, {"id": 2}, {"id": 3}]`)
+	if got := countRows(); got != 3 {
+		t.Errorf("expected reloaded table with 3 rows, got %d", got)
+	}
+}
+
+func TestLoader_LoadFiles_CacheKeepsTypes(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "users.json")
+	dbPath := filepath.Join(dir, "cache.sqlite")
+	writeFile(t, src, `[{"id": 1, "ok": true, "tags": [1, 2], "note": "[1,2]"}]`)
+
+	// Each run opens the database anew, as separate invocations of qo do
+	for run := 1; run <= 2; run++ {
+		database, err := db.Open(dbPath)
+		if err != nil {
+			t.Fatalf("failed to open db: %v", err)
+		}
+		loader := input.NewLoader(database, input.FormatJSON, &input.LoaderOptions{Cache: true})
+		if err := loader.LoadFiles([]string{src}); err != nil {
+			t.Fatalf("run %d: LoadFiles failed: %v", run, err)
+		}
+		if run == 1 {
+			// A cache hit on the next run keeps this change
+			if _, err := database.Exec("UPDATE users SET id = 2"); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+		}
+
+		columns, err := database.TableColumns("main", "users")
+		if err != nil {
+			t.Fatalf("run %d: TableColumns failed: %v", run, err)
+		}
+		want := []parser.DataType{parser.TypeInteger, parser.TypeBoolean, parser.TypeJSON, parser.TypeText}
+		for i, col := range columns {
+			if col.Type != want[i] {
+				t.Errorf("run %d: column %s has type %s, want %s", run, col.Name, col.Type.Name(), want[i].Name())
+			}
+		}
+
+		query := "SELECT * FROM users"
+		rows, err := database.Query(query)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		colTypes, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("ColumnTypes failed: %v", err)
+		}
+		var id int64
+		if rows.Next() {
+			_ = rows.Scan(&id, new(any), new(any), new(any))
+		}
+		_ = rows.Close()
+		if types := database.ResultTypes(query, colTypes); !slices.Equal(types, want) {
+			t.Errorf("run %d: ResultTypes = %v, want %v", run, types, want)
+		}
+		if run == 2 && id != 2 {
+			t.Errorf("expected the cached table to be reused, got id %d", id)
+		}
+		if err := database.Close(); err != nil {
+			t.Fatalf("failed to close db: %v", err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoader_Stats(t *testing.T) {
+	database, err := db.New()
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	testutil.CloseDB(t, database)
+
+	runStats := stats.New()
+	database.SetStats(runStats)
+	loader := input.NewLoader(database, input.FormatJSON, &input.LoaderOptions{Stats: runStats})
+
+	path := testutil.JSONTestdataPath("multiple.json")
+	if err := loader.LoadFiles([]string{path}); err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+	stdin := `[{"id": 1}, {"id": 2}]`
+	if err := loader.LoadReader(strings.NewReader(stdin), "tmp"); err != nil {
+		t.Fatalf("LoadReader failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+	report := runStats.Report()
+	if want := info.Size() + int64(len(stdin)); report.BytesRead != want {
+		t.Errorf("expected %d bytes read, got %d", want, report.BytesRead)
+	}
+	if len(report.Tables) != 2 || report.Tables[0].Name != "multiple" || report.Tables[0].Rows != 3 ||
+		report.Tables[1].Name != "tmp" || report.Tables[1].Rows != 2 {
+		t.Errorf("unexpected tables: %+v", report.Tables)
+	}
+	if report.Parse <= 0 || report.Insert <= 0 {
+		t.Errorf("expected parse and insert times, got %s and %s", report.Parse, report.Insert)
+	}
+}
@@ -0,0 +1,304 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/extensions"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+// columnarBatchSize is the number of rows per Arrow record batch and Parquet
+// row group.
+const columnarBatchSize = 64 * 1024
+
+// parquetCodecs and arrowCodecs map compression names to codecs, with the
+// default first.
+var (
+	parquetCodecs = []struct {
+		name  string
+		codec compress.Compression
+	}{
+		{"snappy", compress.Codecs.Snappy},
+		{"none", compress.Codecs.Uncompressed},
+		{"gzip", compress.Codecs.Gzip},
+		{"brotli", compress.Codecs.Brotli},
+		{"lz4", compress.Codecs.Lz4Raw},
+		{"zstd", compress.Codecs.Zstd},
+	}
+	arrowCodecs = map[string]ipc.Option{
+		"none": nil,
+		"lz4":  ipc.WithLZ4(),
+		"zstd": ipc.WithZstd(),
+	}
+)
+
+// IsBinaryFormat reports whether a format writes binary data, which is not
+// meant for a terminal.
+func IsBinaryFormat(format Format) bool {
+	return format == FormatParquet || format == FormatArrow
+}
+
+// Compressions returns the compression codecs supported by a format, the
+// default first, or nil if the format is not compressed.
+func Compressions(format Format) []string {
+	switch format {
+	case FormatParquet:
+		names := make([]string, len(parquetCodecs))
+		for i, c := range parquetCodecs {
+			names[i] = c.name
+		}
+		return names
+	case FormatArrow:
+		return []string{"none", "lz4", "zstd"}
+	default:
+		return nil
+	}
+}
+
+func IsValidCompression(format Format, codec string) bool {
+	return slices.Contains(Compressions(format), codec)
+}
+
+// recordSink writes record batches to a columnar file.
+type recordSink interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+// columnarWriter writes rows as Parquet or Arrow IPC, one batch of
+// columnarBatchSize rows at a time. The schema follows the source types of
+// the columns; columns of unknown type get the type of the values in the
+// first batch.
+type columnarWriter struct {
+	out         io.Writer
+	format      Format
+	compression string
+	layout      *objectLayout
+	types       []parser.DataType
+	inferred    []bool // the column type comes from the values of the first batch
+	batch       [][]any
+	written     int // rows written in previous batches
+	schema      *arrow.Schema
+	fields      []int // column of each schema field
+	sink        recordSink
+}
+
+func newColumnarWriter(out io.Writer, format Format, compression string, layout *objectLayout, types []parser.DataType) *columnarWriter {
+	return &columnarWriter{
+		out:         out,
+		format:      format,
+		compression: compression,
+		layout:      layout,
+		types:       columnTypes(types, len(layout.keys)),
+	}
+}
+
+func (w *columnarWriter) Begin() error { return nil }
+
+func (w *columnarWriter) WriteRow(row []any) error {
+	w.batch = append(w.batch, row)
+	if len(w.batch) < columnarBatchSize {
+		return nil
+	}
+	return w.flushBatch()
+}
+
+func (w *columnarWriter) End() error {
+	if err := w.flushBatch(); err != nil {
+		return err
+	}
+	if err := w.sink.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", w.format, err)
+	}
+	return nil
+}
+
+// flushBatch writes the buffered rows as a record batch, creating the file
+// with the schema derived from the first batch.
+func (w *columnarWriter) flushBatch() error {
+	if w.sink == nil {
+		w.inferred = make([]bool, len(w.types))
+		for i, typ := range w.types {
+			w.inferred[i] = typ == parser.TypeNull
+		}
+		inferTypes(w.types, w.batch)
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	rec, err := w.record()
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+	w.written += len(w.batch)
+	w.batch = w.batch[:0]
+	if err := w.sink.Write(rec); err != nil {
+		return fmt.Errorf("failed to write %s: %w", w.format, err)
+	}
+	return nil
+}
+
+// open creates the schema and the file writer.
+func (w *columnarWriter) open() error {
+	var fields []arrow.Field
+	for i, key := range w.layout.keys {
+		if w.layout.skip[i] {
+			continue
+		}
+		fields = append(fields, arrow.Field{Name: key, Type: arrowType(w.types[i]), Nullable: true})
+		w.fields = append(w.fields, i)
+	}
+	w.schema = arrow.NewSchema(fields, nil)
+
+	// The file writers close their destination, which belongs to the caller
+	out := struct{ io.Writer }{w.out}
+	var err error
+	if w.format == FormatParquet {
+		w.sink, err = newParquetSink(out, w.schema, w.compression)
+	} else {
+		w.sink, err = newArrowSink(out, w.schema, w.compression)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s writer: %w", w.format, err)
+	}
+	return nil
+}
+
+func newParquetSink(out io.Writer, schema *arrow.Schema, compression string) (recordSink, error) {
+	codec := parquetCodecs[0].codec
+	for _, c := range parquetCodecs {
+		if c.name == compression {
+			codec = c.codec
+		}
+	}
+	props := parquet.NewWriterProperties(
+		parquet.WithCompression(codec),
+		parquet.WithMaxRowGroupLength(columnarBatchSize),
+	)
+	return pqarrow.NewFileWriter(schema, out, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+}
+
+func newArrowSink(out io.Writer, schema *arrow.Schema, compression string) (recordSink, error) {
+	opts := []ipc.Option{ipc.WithSchema(schema)}
+	if opt := arrowCodecs[compression]; opt != nil {
+		opts = append(opts, opt)
+	}
+	return ipc.NewFileWriter(out, opts...)
+}
+
+// arrowType returns the Arrow type of a data type. JSON columns are strings
+// marked with the JSON extension type, which Parquet stores as JSON.
+func arrowType(typ parser.DataType) arrow.DataType {
+	switch typ {
+	case parser.TypeInteger:
+		return arrow.PrimitiveTypes.Int64
+	case parser.TypeReal:
+		return arrow.PrimitiveTypes.Float64
+	case parser.TypeBoolean:
+		return arrow.FixedWidthTypes.Boolean
+	case parser.TypeJSON:
+		jsonType, err := extensions.NewJSONType(arrow.BinaryTypes.String)
+		if err == nil {
+			return jsonType
+		}
+	}
+	return arrow.BinaryTypes.String
+}
+
+// record builds a record batch of the buffered rows.
+func (w *columnarWriter) record() (arrow.Record, error) {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, w.schema)
+	defer b.Release()
+
+	for f, i := range w.fields {
+		fb := b.Field(f)
+		if eb, ok := fb.(*array.ExtensionBuilder); ok {
+			fb = eb.StorageBuilder()
+		}
+		for n, row := range w.batch {
+			if !appendValue(fb, row[i], w.types[i]) {
+				return nil, w.mismatch(i, w.written+n+1, row[i])
+			}
+		}
+	}
+	return b.NewRecord(), nil
+}
+
+// mismatch returns the error for a value in row that does not fit the type of
+// column i.
+func (w *columnarWriter) mismatch(i, row int, val any) error {
+	text := cellText(val)
+	if _, ok := val.(string); ok {
+		text = strconv.Quote(text)
+	}
+	key, typ := w.layout.keys[i], w.types[i].Name()
+	if w.inferred[i] {
+		return fmt.Errorf("column %q: row %d holds %s, which does not fit the %s type inferred from the first %d rows (CAST the column to give it one type)",
+			key, row, text, typ, columnarBatchSize)
+	}
+	return fmt.Errorf("column %q: row %d holds %s, which does not fit its %s type", key, row, text, typ)
+}
+
+// appendValue appends a normalized value to a builder of the column type typ.
+// It reports false if the value does not fit the type.
+func appendValue(b array.Builder, val any, typ parser.DataType) bool {
+	if val == nil {
+		b.AppendNull()
+		return true
+	}
+
+	switch b := b.(type) {
+	case *array.Int64Builder:
+		if v, ok := val.(int64); ok {
+			b.Append(v)
+			return true
+		}
+	case *array.Float64Builder:
+		switch v := val.(type) {
+		case float64:
+			b.Append(v)
+			return true
+		case int64:
+			b.Append(float64(v))
+			return true
+		}
+	case *array.BooleanBuilder:
+		switch v := val.(type) {
+		case bool:
+			b.Append(v)
+			return true
+		case int64:
+			b.Append(v != 0)
+			return true
+		}
+	case *array.StringBuilder:
+		if typ != parser.TypeJSON {
+			b.Append(cellText(val))
+			return true
+		}
+		text, err := json.Marshal(val)
+		if err != nil {
+			return false
+		}
+		b.Append(string(text))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package output_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+
+	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/testutil"
+)
+
+// columnarQuery returns rows of each kind of value, with NULLs in every
+// column but id.
+const columnarQuery = `WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 70000)
+	SELECT n AS id,
+		CASE WHEN n % 2 = 0 THEN n + 0.5 END AS half,
+		CASE WHEN n % 3 = 0 THEN 'n' || n END AS name,
+		CASE WHEN n % 5 = 0 THEN json_array(n) END AS tags
+	FROM seq`
+
+func TestPrinter_PrintRows_Parquet(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	rows, err := db.Query(columnarQuery)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	testutil.CloseRows(t, rows)
+
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatParquet, Output: &buf, Compression: "zstd"})
+	if err := p.PrintRows(rows); err != nil {
+		t.Fatalf("PrintRows failed: %v", err)
+	}
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to open parquet: %v", err)
+	}
+	if n := reader.NumRowGroups(); n != 2 {
+		t.Errorf("expected 2 row groups, got %d", n)
+	}
+	fr, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("failed to read parquet: %v", err)
+	}
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read parquet: %v", err)
+	}
+	defer table.Release()
+
+	checkColumnarSchema(t, table.Schema().Fields()[:3])
+	if typ := reader.MetaData().Schema.Column(3).LogicalType(); !typ.Equals(schema.JSONLogicalType{}) {
+		t.Errorf("tags logical type = %s, want JSON", typ)
+	}
+	if table.NumRows() != 70000 {
+		t.Errorf("expected 70000 rows, got %d", table.NumRows())
+	}
+}
+
+func TestPrinter_PrintRows_Arrow(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	rows, err := db.Query(columnarQuery + " LIMIT 10")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	testutil.CloseRows(t, rows)
+
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatArrow, Output: &buf, Compression: "lz4"})
+	if err := p.PrintRows(rows); err != nil {
+		t.Fatalf("PrintRows failed: %v", err)
+	}
+
+	reader, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to open arrow file: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+	checkColumnarSchema(t, reader.Schema().Fields())
+
+	rec, err := reader.Record(0)
+	if err != nil {
+		t.Fatalf("failed to read record: %v", err)
+	}
+	if rec.NumRows() != 10 {
+		t.Fatalf("expected 10 rows, got %d", rec.NumRows())
+	}
+	if got := rec.Column(2).ValueStr(2); got != "n3" {
+		t.Errorf("name of row 3 = %q, want n3", got)
+	}
+	if !rec.Column(3).IsNull(0) || rec.Column(3).ValueStr(4) != "[5]" {
+		t.Errorf("unexpected tags column: %v", rec.Column(3))
+	}
+}
+
+func checkColumnarSchema(t *testing.T, fields []arrow.Field) {
+	t.Helper()
+	want := []string{"id: type=int64", "half: type=float64", "name: type=utf8", "tags: type=extension<arrow.json"}
+	for i, field := range fields {
+		if !strings.HasPrefix(field.String(), want[i]) {
+			t.Errorf("field %d = %s, want %s", i, field, want[i])
+		}
+	}
+}
+
+func TestPrinter_PrintData_ColumnarTypeMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatArrow, Output: &buf})
+	err := p.PrintData([]string{"v"}, [][]any{{int64(1)}, {"text"}})
+	if err != nil {
+		t.Fatalf("expected mixed values in one batch to be written as text, got %v", err)
+	}
+
+	// Values after the first batch must fit the type inferred from it
+	data := make([][]any, 70000)
+	for i := range data {
+		data[i] = []any{int64(i)}
+	}
+	data[len(data)-1] = []any{"text"}
+	err = p.PrintData([]string{"v"}, data)
+	want := `column "v": row 70000 holds "text", which does not fit the INTEGER type inferred from the first 65536 rows`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestIsValidCompression(t *testing.T) {
+	tests := []struct {
+		format output.Format
+		codec  string
+		want   bool
+	}{
+		{output.FormatParquet, "snappy", true},
+		{output.FormatParquet, "zstd", true},
+		{output.FormatArrow, "lz4", true},
+		{output.FormatArrow, "gzip", false},
+		{output.FormatCSV, "zstd", false},
+	}
+	for _, tt := range tests {
+		if got := output.IsValidCompression(tt.format, tt.codec); got != tt.want {
+			t.Errorf("IsValidCompression(%s, %s) = %v, want %v", tt.format, tt.codec, got, tt.want)
+		}
+	}
+}
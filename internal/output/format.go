@@ -10,10 +10,21 @@ const (
 	FormatJSONL Format = "jsonl"
 	FormatCSV   Format = "csv"
 	FormatTSV   Format = "tsv"
+
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatYAML     Format = "yaml"
+	FormatXML      Format = "xml"
+	FormatTOML     Format = "toml"
+	FormatSQL      Format = "sql"
+	FormatParquet  Format = "parquet"
+	FormatArrow    Format = "arrow"
 )
 
 func Formats() []string {
-	return []string{string(FormatTable), string(FormatJSON), string(FormatJSONL), string(FormatCSV), string(FormatTSV)}
+	return []string{string(FormatTable), string(FormatJSON), string(FormatJSONL), string(FormatCSV), string(FormatTSV),
+		string(FormatMarkdown), string(FormatHTML), string(FormatYAML), string(FormatXML), string(FormatTOML),
+		string(FormatSQL), string(FormatParquet), string(FormatArrow)}
 }
 
 func IsValidFormat(format string) bool {
@@ -8,8 +8,8 @@ import (
 
 func TestFormats(t *testing.T) {
 	formats := output.Formats()
-	if len(formats) != 5 {
-		t.Errorf("expected 5 formats, got %d", len(formats))
+	if len(formats) != 13 {
+		t.Errorf("expected 13 formats, got %d", len(formats))
 	}
 }
 
@@ -23,8 +23,16 @@ func TestIsValidFormat(t *testing.T) {
 		{"jsonl", true},
 		{"csv", true},
 		{"tsv", true},
+		{"markdown", true},
+		{"html", true},
+		{"yaml", true},
+		{"xml", true},
+		{"toml", true},
+		{"sql", true},
+		{"parquet", true},
+		{"arrow", true},
 		{"TABLE", false}, // case sensitive
-		{"yaml", false},
+		{"yml", false},
 		{"", false},
 	}
 
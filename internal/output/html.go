@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bufio"
+	"html"
+)
+
+// htmlWriter writes rows as an HTML table, one row at a time. With full set,
+// the table is wrapped in a complete HTML document.
+type htmlWriter struct {
+	out     *bufio.Writer
+	columns []string
+	full    bool
+}
+
+const htmlDocumentStart = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>qo</title>
+</head>
+<body>
+`
+
+const htmlDocumentEnd = `</body>
+</html>
+`
+
+func (w *htmlWriter) Begin() error {
+	if w.full {
+		_, _ = w.out.WriteString(htmlDocumentStart)
+	}
+	_, _ = w.out.WriteString("<table>\n<thead>\n<tr>")
+	for _, col := range w.columns {
+		_, _ = w.out.WriteString("<th>" + html.EscapeString(col) + "</th>")
+	}
+	_, err := w.out.WriteString("</tr>\n</thead>\n<tbody>\n")
+	return err
+}
+
+func (w *htmlWriter) WriteRow(row []any) error {
+	_, _ = w.out.WriteString("<tr>")
+	for _, val := range row {
+		_, _ = w.out.WriteString("<td>" + html.EscapeString(cellText(val)) + "</td>")
+	}
+	_, err := w.out.WriteString("</tr>\n")
+	return err
+}
+
+func (w *htmlWriter) End() error {
+	_, _ = w.out.WriteString("</tbody>\n</table>\n")
+	if w.full {
+		_, _ = w.out.WriteString(htmlDocumentEnd)
+	}
+	return w.out.Flush()
+}
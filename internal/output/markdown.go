@@ -0,0 +1,113 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// markdownWriter buffers all rows and renders them as a GitHub-flavored
+// Markdown table, with numeric columns aligned right.
+type markdownWriter struct {
+	out     io.Writer
+	columns []string
+	rows    [][]string
+	numbers []bool // column holds a number
+	others  []bool // column holds a value other than a number or NULL
+}
+
+func (w *markdownWriter) Begin() error {
+	w.numbers = make([]bool, len(w.columns))
+	w.others = make([]bool, len(w.columns))
+	return nil
+}
+
+func (w *markdownWriter) WriteRow(row []any) error {
+	r := make([]string, len(row))
+	for i, val := range row {
+		switch val.(type) {
+		case nil:
+		case int, int64, float64:
+			w.numbers[i] = true
+		default:
+			w.others[i] = true
+		}
+		r[i] = markdownEscape(cellText(val))
+	}
+	w.rows = append(w.rows, r)
+	return nil
+}
+
+func (w *markdownWriter) End() error {
+	header := make([]string, len(w.columns))
+	widths := make([]int, len(w.columns))
+	for i, col := range w.columns {
+		header[i] = markdownEscape(col)
+		widths[i] = max(lipgloss.Width(header[i]), 3)
+	}
+	for _, row := range w.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	var sb strings.Builder
+	w.writeLine(&sb, header, widths)
+	sep := make([]string, len(w.columns))
+	for i, width := range widths {
+		if w.numeric(i) {
+			sep[i] = strings.Repeat("-", width-1) + ":"
+		} else {
+			sep[i] = ":" + strings.Repeat("-", width-1)
+		}
+	}
+	w.writeLine(&sb, sep, nil)
+	for _, row := range w.rows {
+		w.writeLine(&sb, row, widths)
+	}
+
+	_, err := io.WriteString(w.out, sb.String())
+	return err
+}
+
+// numeric reports whether column i holds numbers only, apart from NULL.
+func (w *markdownWriter) numeric(i int) bool {
+	return w.numbers[i] && !w.others[i]
+}
+
+// writeLine writes a table row, padding cells to widths (numeric columns on
+// the left); nil widths write cells as they are.
+func (w *markdownWriter) writeLine(sb *strings.Builder, cells []string, widths []int) {
+	sb.WriteString("|")
+	for i, cell := range cells {
+		pad := ""
+		if widths != nil {
+			pad = strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+		}
+		if w.numeric(i) {
+			fmt.Fprintf(sb, " %s%s |", pad, cell)
+		} else {
+			fmt.Fprintf(sb, " %s%s |", cell, pad)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// markdownReplacer makes text safe for a table cell, which cannot hold pipes
+// or line breaks, and keeps renderers from reading it as HTML.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
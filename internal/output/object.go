@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// DuplicatePolicy decides how objects in JSON output name columns that share
+// a name, e.g. "id" from both sides of a join.
+type DuplicatePolicy string
+
+const (
+	DuplicateSuffix DuplicatePolicy = "suffix" // number later columns: id, id_1, id_2
+	DuplicateLast   DuplicatePolicy = "last"   // keep only the last column of each name
+	DuplicateError  DuplicatePolicy = "error"  // fail before writing any row
+)
+
+func DuplicatePolicies() []string {
+	return []string{string(DuplicateSuffix), string(DuplicateLast), string(DuplicateError)}
+}
+
+func IsValidDuplicatePolicy(policy string) bool {
+	return slices.Contains(DuplicatePolicies(), policy)
+}
+
+// objectLayout maps the columns of a result to the keys of JSON objects.
+type objectLayout struct {
+	keys []string // key of each column
+	skip []bool   // columns left out of objects
+}
+
+// newObjectLayout names the keys of columns according to the policy.
+func newObjectLayout(columns []string, policy DuplicatePolicy) (*objectLayout, error) {
+	keys := make([]string, len(columns))
+	skip := make([]bool, len(columns))
+	switch policy {
+	case DuplicateLast:
+		last := make(map[string]int, len(columns))
+		for i, col := range columns {
+			last[col] = i
+		}
+		for i, col := range columns {
+			keys[i] = col
+			skip[i] = last[col] != i
+		}
+	case DuplicateError:
+		seen := make(map[string]bool, len(columns))
+		for i, col := range columns {
+			if seen[col] {
+				return nil, fmt.Errorf("duplicate column name %q (rename it with AS)", col)
+			}
+			seen[col] = true
+			keys[i] = col
+		}
+	default:
+		used := make(map[string]bool, len(columns))
+		for _, col := range columns {
+			used[col] = true
+		}
+		seen := make(map[string]bool, len(columns))
+		for i, col := range columns {
+			// Suffixed names must not clash with other columns, e.g. a real "id_1"
+			key := col
+			for n := 1; seen[key] || key != col && used[key]; n++ {
+				key = col + "_" + strconv.Itoa(n)
+			}
+			seen[key] = true
+			keys[i] = key
+		}
+	}
+	return &objectLayout{keys: keys, skip: skip}, nil
+}
+
+// object returns a row as an object with its keys in column order.
+func (l *objectLayout) object(row []any) object {
+	return object{layout: l, values: row}
+}
+
+// object is a JSON object whose keys are written in column order.
+type object struct {
+	layout *objectLayout
+	values []any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for i, key := range o.layout.keys {
+		if o.layout.skip[i] {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %s: %w", key, err)
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"bufio"
 	"database/sql"
 	"encoding/csv"
 	"encoding/json"
@@ -10,13 +11,30 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
 )
 
 type Options struct {
-	Format Format
-	Output io.Writer
+	Format     Format
+	Output     io.Writer
+	Duplicates DuplicatePolicy // naming of duplicate columns in JSON objects (default: suffix)
+	Types      TypeResolver    // source types of result columns; nil to guess from the values
+	HTMLFull   bool            // wrap HTML output in a complete document
+	XMLRoot    string          // name of the root element of XML output (default: rows)
+	XMLRow     string          // name of the element of each row in XML output (default: row)
+	SQLDialect SQLDialect      // dialect of SQL output (default: sqlite)
+	SQLTable   string          // table SQL output inserts into (default: result)
+
+	// Compression is the codec of Parquet and Arrow output, one of
+	// Compressions(Format); empty for the default.
+	Compression string
 }
 
+// TypeResolver returns the type each result column had in its source data, or
+// TypeNull where it is unknown (see NormalizeTypedValue).
+type TypeResolver func(columns []*sql.ColumnType) []parser.DataType
+
 func DefaultOptions() *Options {
 	return &Options{
 		Format: FormatJSON,
@@ -39,155 +57,262 @@ func NewPrinter(opts *Options) *Printer {
 	return &Printer{opts: opts}
 }
 
-// Prints SQL results.
+// Prints SQL results. Rows are written as they are read, except for the
+// table format, which needs all rows to size its columns.
 func (p *Printer) PrintRows(rows *sql.Rows) error {
 	columns, err := rows.Columns()
 	if err != nil {
 		return fmt.Errorf("failed to get columns: %w", err)
 	}
 
-	data, err := p.scanRows(rows, len(columns))
+	// Without a resolver, types stay nil and values are normalized by guessing
+	var types []parser.DataType
+	if p.opts.Types != nil {
+		columnTypes, err := rows.ColumnTypes()
+		if err != nil {
+			return fmt.Errorf("failed to get column types: %w", err)
+		}
+		types = p.opts.Types(columnTypes)
+	}
+
+	w, err := p.newRowWriter(columns, types)
 	if err != nil {
 		return err
 	}
-
-	switch p.opts.Format {
-	case FormatJSON:
-		return p.printJSON(columns, data)
-	case FormatJSONL:
-		return p.printJSONL(columns, data)
-	case FormatCSV:
-		return p.printCSV(columns, data, ',')
-	case FormatTSV:
-		return p.printCSV(columns, data, '\t')
-	default:
-		return p.printTable(columns, data)
+	if err := w.Begin(); err != nil {
+		return err
 	}
-}
 
-// Scans all rows into a slice of slices.
-func (p *Printer) scanRows(rows *sql.Rows, numCols int) ([][]any, error) {
-	var result [][]any
-
-	values := make([]any, numCols)
-	valuePtrs := make([]any, numCols)
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
 	for i := range values {
 		valuePtrs[i] = &values[i]
 	}
 
 	for rows.Next() {
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		row := make([]any, numCols)
+		row := make([]any, len(columns))
 		for i, val := range values {
-			row[i] = NormalizeValue(val)
+			if types != nil {
+				row[i] = NormalizeTypedValue(val, types[i])
+			} else {
+				row[i] = NormalizeValue(val)
+			}
+		}
+		if err := w.WriteRow(row); err != nil {
+			return err
 		}
-		result = append(result, row)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return result, nil
+	return w.End()
+}
+
+// Prints rows of values that are already normalized (see NormalizeValue).
+func (p *Printer) PrintData(columns []string, data [][]any) error {
+	w, err := p.newRowWriter(columns, nil)
+	if err != nil {
+		return err
+	}
+	if err := w.Begin(); err != nil {
+		return err
+	}
+	for _, row := range data {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.End()
 }
 
-// Print result formatted as a table.
-func (p *Printer) printTable(columns []string, data [][]any) error {
-	rows := make([][]string, len(data))
+// rowWriter writes a result one row at a time in an output format.
+type rowWriter interface {
+	Begin() error
+	WriteRow(row []any) error
+	End() error
+}
 
-	for i, row := range data {
-		r := make([]string, len(row))
-		for j, val := range row {
-			r[j] = FormatValueForDisplay(val)
+// newRowWriter returns the writer for the configured format. types holds the
+// source type of each column, if known (see NormalizeTypedValue).
+func (p *Printer) newRowWriter(columns []string, types []parser.DataType) (rowWriter, error) {
+	switch p.opts.Format {
+	case FormatJSON, FormatJSONL, FormatYAML, FormatTOML, FormatSQL, FormatParquet, FormatArrow:
+		layout, err := newObjectLayout(columns, p.opts.Duplicates)
+		if err != nil {
+			return nil, err
+		}
+		if IsBinaryFormat(p.opts.Format) {
+			return newColumnarWriter(p.opts.Output, p.opts.Format, p.opts.Compression, layout, types), nil
+		}
+		out := bufio.NewWriter(p.opts.Output)
+		switch p.opts.Format {
+		case FormatJSONL:
+			return &jsonlWriter{out: out, enc: json.NewEncoder(out), layout: layout}, nil
+		case FormatYAML:
+			return &yamlWriter{out: out, layout: layout}, nil
+		case FormatTOML:
+			return &tomlWriter{out: out, layout: layout}, nil
+		case FormatSQL:
+			return newSQLWriter(out, layout, types, p.opts.SQLDialect, p.opts.SQLTable), nil
 		}
-		rows[i] = r
+		return &jsonWriter{out: out, layout: layout}, nil
+	case FormatXML:
+		return newXMLWriter(bufio.NewWriter(p.opts.Output), columns, p.opts.XMLRoot, p.opts.XMLRow), nil
+	case FormatCSV:
+		return newCSVWriter(p.opts.Output, columns, ','), nil
+	case FormatTSV:
+		return newCSVWriter(p.opts.Output, columns, '\t'), nil
+	case FormatMarkdown:
+		return &markdownWriter{out: p.opts.Output, columns: columns}, nil
+	case FormatHTML:
+		return &htmlWriter{out: bufio.NewWriter(p.opts.Output), columns: columns, full: p.opts.HTMLFull}, nil
+	default:
+		return &tableWriter{out: p.opts.Output, columns: columns}, nil
+	}
+}
+
+// tableWriter buffers all rows and renders them as a table at the end.
+type tableWriter struct {
+	out     io.Writer
+	columns []string
+	rows    [][]string
+}
+
+func (w *tableWriter) Begin() error { return nil }
+
+func (w *tableWriter) WriteRow(row []any) error {
+	r := make([]string, len(row))
+	for j, val := range row {
+		r[j] = FormatValueForDisplay(val)
 	}
+	w.rows = append(w.rows, r)
+	return nil
+}
 
+func (w *tableWriter) End() error {
 	// Create renderer that detects TTY for color support
-	renderer := lipgloss.NewRenderer(p.opts.Output)
+	renderer := lipgloss.NewRenderer(w.out)
 
 	t := table.New().
 		Border(lipgloss.NormalBorder()).
 		BorderStyle(renderer.NewStyle().Foreground(lipgloss.Color("240"))).
-		Headers(columns...).
-		Rows(rows...)
+		Headers(w.columns...).
+		Rows(w.rows...)
 
 	t.StyleFunc(func(row, col int) lipgloss.Style {
 		return renderer.NewStyle().Padding(0, 1)
 	})
 
-	_, _ = fmt.Fprintln(p.opts.Output, t.Render())
+	_, _ = fmt.Fprintln(w.out, t.Render())
 
 	return nil
 }
 
-// rowToMap converts a row to a map with column names as keys.
-func (p *Printer) rowToMap(columns []string, row []any) map[string]any {
-	obj := make(map[string]any)
-	for j, col := range columns {
-		obj[col] = row[j]
-	}
-	return obj
+// jsonWriter writes rows as an indented JSON array, one element at a time.
+type jsonWriter struct {
+	out    *bufio.Writer
+	layout *objectLayout
+	count  int
 }
 
-// Print result formatted as a JSON.
-func (p *Printer) printJSON(columns []string, data [][]any) error {
-	result := make([]map[string]any, len(data))
-	for i, row := range data {
-		result[i] = p.rowToMap(columns, row)
+func (w *jsonWriter) Begin() error { return nil }
+
+func (w *jsonWriter) WriteRow(row []any) error {
+	obj, err := json.MarshalIndent(w.layout.object(row), "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode row: %w", err)
 	}
 
-	encoder := json.NewEncoder(p.opts.Output)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(result)
+	sep := ",\n  "
+	if w.count == 0 {
+		sep = "[\n  "
+	}
+	w.count++
+	_, err = w.out.WriteString(sep + string(obj))
+	return err
 }
 
-// Print result formatted as JSON Lines (one JSON object per line).
-func (p *Printer) printJSONL(columns []string, data [][]any) error {
-	encoder := json.NewEncoder(p.opts.Output)
-	for _, row := range data {
-		if err := encoder.Encode(p.rowToMap(columns, row)); err != nil {
-			return err
-		}
+func (w *jsonWriter) End() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
 	}
-	return nil
+	if _, err := w.out.WriteString(end); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// jsonlWriter writes rows as JSON Lines (one JSON object per line).
+type jsonlWriter struct {
+	out    *bufio.Writer
+	enc    *json.Encoder
+	layout *objectLayout
+}
+
+func (w *jsonlWriter) Begin() error { return nil }
+
+func (w *jsonlWriter) WriteRow(row []any) error {
+	return w.enc.Encode(w.layout.object(row))
 }
 
-// Print result formatted as a CSV (or TSV with tab delimiter).
-func (p *Printer) printCSV(columns []string, data [][]any, delimiter rune) error {
-	w := csv.NewWriter(p.opts.Output)
+func (w *jsonlWriter) End() error { return w.out.Flush() }
+
+// csvWriter writes rows as CSV (or TSV with tab delimiter).
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func newCSVWriter(out io.Writer, columns []string, delimiter rune) *csvWriter {
+	w := csv.NewWriter(out)
 	w.Comma = delimiter
-	defer w.Flush()
+	return &csvWriter{w: w, columns: columns}
+}
 
-	if err := w.Write(columns); err != nil {
+func (w *csvWriter) Begin() error {
+	if err := w.w.Write(w.columns); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
+	return nil
+}
 
-	for _, row := range data {
-		record := make([]string, len(row))
-		for i, val := range row {
-			switch v := val.(type) {
-			case nil:
-				record[i] = ""
-			case map[string]any, []any:
-				// Convert nested objects/arrays back to JSON string
-				b, err := json.Marshal(v)
-				if err != nil {
-					record[i] = fmt.Sprintf("%v", v)
-				} else {
-					record[i] = string(b)
-				}
-			default:
-				record[i] = fmt.Sprintf("%v", v)
-			}
-		}
-		if err := w.Write(record); err != nil {
-			return fmt.Errorf("failed to write CSV row: %w", err)
-		}
+func (w *csvWriter) WriteRow(row []any) error {
+	record := make([]string, len(row))
+	for i, val := range row {
+		record[i] = cellText(val)
+	}
+	if err := w.w.Write(record); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
 	}
+	return nil
+}
 
-	return w.Error()
+func (w *csvWriter) End() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// cellText converts a normalized value to the text of a cell in text-based
+// formats: NULL is empty and nested objects/arrays are written as JSON.
+func cellText(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case map[string]any, []any:
+		// Convert nested objects/arrays back to JSON string
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
 }
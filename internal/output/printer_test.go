@@ -2,12 +2,15 @@ package output_test
 
 import (
 	"bytes"
+	"database/sql"
+	"slices"
 	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/parser"
 	"github.com/kiki-ki/go-qo/internal/testutil"
 )
 
@@ -165,6 +168,147 @@ func TestPrinter_PrintRows(t *testing.T) {
 			format: output.FormatTSV,
 			want:   "id\tmeta\n1\t\"{\"\"data\"\":[1,2,3],\"\"name\"\":\"\"Alice\"\"}\"\n",
 		},
+		{
+			name: "markdown format",
+			setup: `
+				CREATE TABLE test (id INTEGER, name TEXT, score REAL);
+				INSERT INTO test VALUES (1, 'Alice', 9.5), (10, 'Bob', NULL);
+			`,
+			query:  "SELECT * FROM test ORDER BY id",
+			format: output.FormatMarkdown,
+			want: `|  id | name  | score |
+| --: | :---- | ----: |
+|   1 | Alice |   9.5 |
+|  10 | Bob   |       |
+`,
+		},
+		{
+			name: "markdown format with special characters",
+			setup: `
+				CREATE TABLE test (id INTEGER, value TEXT);
+				INSERT INTO test VALUES (1, 'a|b' || char(10) || 'c');
+			`,
+			query:  "SELECT value AS [x|y], id || '' AS id FROM test",
+			format: output.FormatMarkdown,
+			want: `| x\|y      | id  |
+| :-------- | :-- |
+| a\|b<br>c | 1   |
+`,
+		},
+		{
+			name: "markdown format with html",
+			setup: `
+				CREATE TABLE test (value TEXT);
+				INSERT INTO test VALUES ('<x> a & b');
+			`,
+			query:  "SELECT value FROM test",
+			format: output.FormatMarkdown,
+			want: `| value               |
+| :------------------ |
+| &lt;x&gt; a &amp; b |
+`,
+		},
+		{
+			name:   "markdown format with empty result",
+			setup:  `CREATE TABLE test (id INTEGER);`,
+			query:  "SELECT * FROM test",
+			format: output.FormatMarkdown,
+			want:   "| id  |\n| :-- |\n",
+		},
+		{
+			name: "yaml format",
+			setup: `
+				CREATE TABLE test (name TEXT, id INTEGER, meta TEXT);
+				INSERT INTO test VALUES ('Alice', 1, '{"tags":["a",null]}'), ('true', 2, NULL);
+			`,
+			query:  "SELECT * FROM test ORDER BY id",
+			format: output.FormatYAML,
+			want: `- name: Alice
+  id: 1
+  meta:
+    tags:
+      - a
+      - null
+- name: "true"
+  id: 2
+  meta: null
+`,
+		},
+		{
+			name:   "yaml format with empty result",
+			setup:  `CREATE TABLE test (id INTEGER);`,
+			query:  "SELECT * FROM test",
+			format: output.FormatYAML,
+			want:   "[]\n",
+		},
+		{
+			name: "xml format",
+			setup: `
+				CREATE TABLE test (id INTEGER, [first name] TEXT, meta TEXT);
+				INSERT INTO test VALUES (1, 'A&B', '{"b":[1,null],"a":{"c":true}}'), (2, NULL, NULL);
+			`,
+			query:  "SELECT * FROM test ORDER BY id",
+			format: output.FormatXML,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<rows>
+  <row>
+    <id>1</id>
+    <first_name>A&amp;B</first_name>
+    <meta>
+      <a>
+        <c>true</c>
+      </a>
+      <b>
+        <item>1</item>
+        <item/>
+      </b>
+    </meta>
+  </row>
+  <row>
+    <id>2</id>
+  </row>
+</rows>
+`,
+		},
+		{
+			name: "toml format",
+			setup: `
+				CREATE TABLE test (id INTEGER, name TEXT, score REAL, meta TEXT);
+				INSERT INTO test VALUES (1, 'say "hi"', 1.5, '{"tags":["a",2],"x":null}'), (2, NULL, NULL, NULL);
+			`,
+			query:  "SELECT id, name, score, meta, id AS [my id] FROM test ORDER BY id",
+			format: output.FormatTOML,
+			want: `[[rows]]
+id = 1
+name = "say \"hi\""
+score = 1.5
+meta = { tags = ["a", 2] }
+"my id" = 1
+
+[[rows]]
+id = 2
+"my id" = 2
+`,
+		},
+		{
+			name: "html format",
+			setup: `
+				CREATE TABLE test (id INTEGER, value TEXT);
+				INSERT INTO test VALUES (1, '<a href="x">&</a>'), (2, NULL);
+			`,
+			query:  "SELECT id AS [<id>], value FROM test ORDER BY id",
+			format: output.FormatHTML,
+			want: `<table>
+<thead>
+<tr><th>&lt;id&gt;</th><th>value</th></tr>
+</thead>
+<tbody>
+<tr><td>1</td><td>&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;</td></tr>
+<tr><td>2</td><td></td></tr>
+</tbody>
+</table>
+`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -314,3 +458,328 @@ func TestPrinter_PrintRows_NoANSICodesWhenNotTTY(t *testing.T) {
 		t.Error("table output should not contain ANSI escape codes when output is not a TTY")
 	}
 }
+
+func TestPrinter_PrintRows_Streams(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	// The query fails after producing many rows; rows written before the
+	// failure show that output is not buffered until the end.
+	query := `WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 10001)
+		SELECT n, CASE WHEN n > 10000 THEN json('{') ELSE 'row' END AS v FROM seq`
+
+	for _, format := range []output.Format{output.FormatJSON, output.FormatJSONL, output.FormatCSV, output.FormatTSV, output.FormatHTML,
+		output.FormatYAML, output.FormatXML, output.FormatTOML} {
+		t.Run(string(format), func(t *testing.T) {
+			rows, err := db.Query(query)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			testutil.CloseRows(t, rows)
+
+			var buf bytes.Buffer
+			p := output.NewPrinter(&output.Options{Format: format, Output: &buf})
+			if err := p.PrintRows(rows); err == nil {
+				t.Fatal("expected the query to fail")
+			}
+			if !strings.Contains(buf.String(), "row") {
+				t.Error("expected rows to be written before the query failed")
+			}
+		})
+	}
+}
+
+func TestPrinter_PrintData_HTMLFull(t *testing.T) {
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatHTML, Output: &buf, HTMLFull: true})
+	if err := p.PrintData([]string{"id"}, [][]any{{int64(1)}}); err != nil {
+		t.Fatalf("PrintData failed: %v", err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "<!DOCTYPE html>\n") || !strings.HasSuffix(got, "</table>\n</body>\n</html>\n") {
+		t.Errorf("expected a complete HTML document, got:\n%s", got)
+	}
+	if !strings.Contains(got, "<tr><td>1</td></tr>") {
+		t.Errorf("expected the table in the document, got:\n%s", got)
+	}
+}
+
+func TestPrinter_PrintData_XMLNames(t *testing.T) {
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatXML, Output: &buf, XMLRoot: "users", XMLRow: "user"})
+	if err := p.PrintData([]string{"1st"}, [][]any{{"a"}}); err != nil {
+		t.Fatalf("PrintData failed: %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<users>
+  <user>
+    <_1st>a</_1st>
+  </user>
+</users>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output mismatch:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestIsValidXMLName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"rows", true},
+		{"my-row.v2", true},
+		{"_row", true},
+		{"1row", false},
+		{"a b", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := output.IsValidXMLName(tt.name); got != tt.want {
+			t.Errorf("IsValidXMLName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrinter_PrintRows_SQL(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE test (id INTEGER, name TEXT, ok INTEGER, meta TEXT, score REAL);
+		INSERT INTO test VALUES (1, 'it''s \', 1, '{"a":1}', 1.5), (2, NULL, 0, NULL, NULL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to setup test data: %v", err)
+	}
+	types := func(columns []*sql.ColumnType) []parser.DataType {
+		return []parser.DataType{parser.TypeInteger, parser.TypeText, parser.TypeBoolean, parser.TypeJSON, parser.TypeNull}
+	}
+
+	tests := []struct {
+		name    string
+		dialect output.SQLDialect
+		table   string
+		types   output.TypeResolver
+		want    string
+	}{
+		{
+			name: "sqlite",
+			want: `CREATE TABLE "result" (
+  "id" INTEGER,
+  "name" TEXT,
+  "ok" INTEGER,
+  "meta" TEXT,
+  "score" REAL
+);
+INSERT INTO "result" ("id", "name", "ok", "meta", "score") VALUES
+  (1, 'it''s \', 1, '{"a":1}', 1.5),
+  (2, NULL, 0, NULL, NULL);
+`,
+		},
+		{
+			name:    "postgres",
+			dialect: output.DialectPostgres,
+			table:   `my "table"`,
+			types:   types,
+			want: `CREATE TABLE "my ""table""" (
+  "id" BIGINT,
+  "name" TEXT,
+  "ok" BOOLEAN,
+  "meta" JSONB,
+  "score" DOUBLE PRECISION
+);
+INSERT INTO "my ""table""" ("id", "name", "ok", "meta", "score") VALUES
+  (1, 'it''s \', TRUE, '{"a":1}', 1.5),
+  (2, NULL, FALSE, NULL, NULL);
+`,
+		},
+		{
+			name:    "mysql",
+			dialect: output.DialectMySQL,
+			table:   "t",
+			types:   types,
+			want: "CREATE TABLE `t` (\n" +
+				"  `id` BIGINT,\n" +
+				"  `name` TEXT,\n" +
+				"  `ok` BOOLEAN,\n" +
+				"  `meta` JSON,\n" +
+				"  `score` DOUBLE\n" +
+				");\n" +
+				"INSERT INTO `t` (`id`, `name`, `ok`, `meta`, `score`) VALUES\n" +
+				"  (1, 'it\\'s \\\\', TRUE, '{\"a\":1}', 1.5),\n" +
+				"  (2, NULL, FALSE, NULL, NULL);\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := db.Query("SELECT * FROM test ORDER BY id")
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			testutil.CloseRows(t, rows)
+
+			var buf bytes.Buffer
+			p := output.NewPrinter(&output.Options{
+				Format:     output.FormatSQL,
+				Output:     &buf,
+				Types:      tt.types,
+				SQLDialect: tt.dialect,
+				SQLTable:   tt.table,
+			})
+			if err := p.PrintRows(rows); err != nil {
+				t.Fatalf("PrintRows failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output mismatch:\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_PrintData_SQLBatches(t *testing.T) {
+	data := make([][]any, 1001)
+	for i := range data {
+		data[i] = []any{int64(i), nil}
+	}
+
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatSQL, Output: &buf})
+	if err := p.PrintData([]string{"id", "id"}, data); err != nil {
+		t.Fatalf("PrintData failed: %v", err)
+	}
+
+	got := buf.String()
+	if n := strings.Count(got, "INSERT INTO"); n != 3 {
+		t.Errorf("expected 3 INSERT statements, got %d", n)
+	}
+	// Columns of unknown type are typed from the values, NULL-only ones as
+	// TEXT, and duplicate names are suffixed
+	if !strings.HasPrefix(got, "CREATE TABLE \"result\" (\n  \"id\" INTEGER,\n  \"id_1\" TEXT\n);\n") {
+		t.Errorf("unexpected CREATE TABLE statement:\n%s", got[:min(len(got), 200)])
+	}
+}
+
+func TestPrinter_PrintData_SQLWidening(t *testing.T) {
+	// Typed as INTEGER from the first batch, then text and decimals
+	data := make([][]any, 1001)
+	for i := range data {
+		data[i] = []any{int64(i), int64(i)}
+	}
+	data[600][1] = "n/a"
+	data[1000][0] = 1.5
+
+	tests := []struct {
+		dialect output.SQLDialect
+		want    []string
+	}{
+		{output.DialectSQLite, nil},
+		{output.DialectPostgres, []string{
+			`ALTER TABLE "result" ALTER COLUMN "b" TYPE TEXT USING "b"::TEXT;`,
+			`ALTER TABLE "result" ALTER COLUMN "a" TYPE DOUBLE PRECISION USING "a"::DOUBLE PRECISION;`,
+		}},
+		{output.DialectMySQL, []string{
+			"ALTER TABLE `result` MODIFY COLUMN `b` TEXT;",
+			"ALTER TABLE `result` MODIFY COLUMN `a` DOUBLE;",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			var buf bytes.Buffer
+			p := output.NewPrinter(&output.Options{Format: output.FormatSQL, Output: &buf, SQLDialect: tt.dialect})
+			if err := p.PrintData([]string{"a", "b"}, data); err != nil {
+				t.Fatalf("PrintData failed: %v", err)
+			}
+
+			var alters []string
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if strings.HasPrefix(line, "ALTER TABLE") {
+					alters = append(alters, line)
+				}
+			}
+			if !slices.Equal(alters, tt.want) {
+				t.Errorf("ALTER statements = %q, want %q", alters, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_PrintData_ColumnOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		columns    []string
+		row        []any
+		duplicates output.DuplicatePolicy
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:    "select order",
+			columns: []string{"name", "id"},
+			row:     []any{"Alice", 1},
+			want:    `{"name":"Alice","id":1}`,
+		},
+		{
+			name:    "suffix by default",
+			columns: []string{"id", "name", "id", "id"},
+			row:     []any{1, "Alice", 2, 3},
+			want:    `{"id":1,"name":"Alice","id_1":2,"id_2":3}`,
+		},
+		{
+			name:    "suffix skips existing names",
+			columns: []string{"id", "id", "id_1"},
+			row:     []any{1, 2, 3},
+			want:    `{"id":1,"id_2":2,"id_1":3}`,
+		},
+		{
+			name:       "keep last",
+			columns:    []string{"id", "name", "id"},
+			row:        []any{1, "Alice", 2},
+			duplicates: output.DuplicateLast,
+			want:       `{"name":"Alice","id":2}`,
+		},
+		{
+			name:       "error",
+			columns:    []string{"id", "id"},
+			row:        []any{1, 2},
+			duplicates: output.DuplicateError,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := output.NewPrinter(&output.Options{Format: output.FormatJSONL, Output: &buf, Duplicates: tt.duplicates})
+			err := p.PrintData(tt.columns, [][]any{tt.row})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for duplicate column")
+				}
+				if buf.Len() != 0 {
+					t.Errorf("expected no output, got %q", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrintData failed: %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_PrintData_JSONColumnOrder(t *testing.T) {
+	var buf bytes.Buffer
+	p := output.NewPrinter(&output.Options{Format: output.FormatJSON, Output: &buf})
+	if err := p.PrintData([]string{"z", "a"}, [][]any{{1, 2}}); err != nil {
+		t.Fatalf("PrintData failed: %v", err)
+	}
+	want := "[\n  {\n    \"z\": 1,\n    \"a\": 2\n  }\n]\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,301 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
+)
+
+// SQLDialect selects the quoting, literals and column types of SQL output.
+type SQLDialect string
+
+const (
+	DialectSQLite   SQLDialect = "sqlite"
+	DialectPostgres SQLDialect = "postgres"
+	DialectMySQL    SQLDialect = "mysql"
+)
+
+// DefaultSQLTable is the table SQL output inserts into when none is given.
+const DefaultSQLTable = "result"
+
+// sqlBatchSize is the number of rows per INSERT statement.
+const sqlBatchSize = 500
+
+func SQLDialects() []string {
+	return []string{string(DialectSQLite), string(DialectPostgres), string(DialectMySQL)}
+}
+
+func IsValidSQLDialect(dialect string) bool {
+	return slices.Contains(SQLDialects(), dialect)
+}
+
+// sqlWriter writes rows as a CREATE TABLE statement followed by INSERT
+// statements of up to sqlBatchSize rows. Columns of unknown type get the type
+// of the values in the first batch, which is buffered before the table is
+// created, and are altered to a wider type when later values do not fit.
+type sqlWriter struct {
+	out      *bufio.Writer
+	dialect  SQLDialect
+	table    string
+	layout   *objectLayout
+	types    []parser.DataType
+	inferred []bool // the column type comes from the values
+	batch    [][]any
+	created  bool
+}
+
+func newSQLWriter(out *bufio.Writer, layout *objectLayout, types []parser.DataType, dialect SQLDialect, table string) *sqlWriter {
+	if dialect == "" {
+		dialect = DialectSQLite
+	}
+	if table == "" {
+		table = DefaultSQLTable
+	}
+	return &sqlWriter{out: out, dialect: dialect, table: table, layout: layout, types: columnTypes(types, len(layout.keys))}
+}
+
+func (w *sqlWriter) Begin() error { return nil }
+
+func (w *sqlWriter) WriteRow(row []any) error {
+	w.batch = append(w.batch, row)
+	if len(w.batch) < sqlBatchSize {
+		return nil
+	}
+	return w.flushBatch()
+}
+
+func (w *sqlWriter) End() error {
+	if err := w.flushBatch(); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// flushBatch writes the buffered rows as an INSERT statement, preceded by the
+// CREATE TABLE statement for the first batch.
+func (w *sqlWriter) flushBatch() error {
+	if !w.created {
+		w.created = true
+		w.inferred = make([]bool, len(w.types))
+		for i, typ := range w.types {
+			w.inferred[i] = typ == parser.TypeNull
+		}
+		inferTypes(w.types, w.batch)
+		if _, err := w.out.WriteString(w.createTable()); err != nil {
+			return err
+		}
+	} else if _, err := w.out.WriteString(w.widenColumns()); err != nil {
+		return err
+	}
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO " + w.quoteIdent(w.table) + " (")
+	sep := ""
+	for i, key := range w.layout.keys {
+		if !w.layout.skip[i] {
+			sb.WriteString(sep + w.quoteIdent(key))
+			sep = ", "
+		}
+	}
+	sb.WriteString(") VALUES\n")
+	for n, row := range w.batch {
+		sb.WriteString("  (")
+		sep := ""
+		for i, val := range row {
+			if !w.layout.skip[i] {
+				sb.WriteString(sep + w.literal(val, w.types[i]))
+				sep = ", "
+			}
+		}
+		if n == len(w.batch)-1 {
+			sb.WriteString(");\n")
+		} else {
+			sb.WriteString("),\n")
+		}
+	}
+	w.batch = w.batch[:0]
+	_, err := w.out.WriteString(sb.String())
+	return err
+}
+
+func (w *sqlWriter) createTable() string {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE " + w.quoteIdent(w.table) + " (\n")
+	var defs []string
+	for i, key := range w.layout.keys {
+		if !w.layout.skip[i] {
+			defs = append(defs, "  "+w.quoteIdent(key)+" "+w.columnType(w.types[i]))
+		}
+	}
+	sb.WriteString(strings.Join(defs, ",\n"))
+	sb.WriteString("\n);\n")
+	return sb.String()
+}
+
+// widenColumns widens the types of inferred columns to hold the values of the
+// batch, e.g. to TEXT for text after numbers, and returns the statements that
+// alter the table to match. SQLite stores any value in any column, so it
+// needs none.
+func (w *sqlWriter) widenColumns() string {
+	var sb strings.Builder
+	for i, typ := range w.types {
+		if !w.inferred[i] || w.layout.skip[i] {
+			continue
+		}
+		widened := typ
+		for _, row := range w.batch {
+			widened = widenType(widened, valueType(row[i]))
+		}
+		if widened == typ {
+			continue
+		}
+		w.types[i] = widened
+
+		table, column, colType := w.quoteIdent(w.table), w.quoteIdent(w.layout.keys[i]), w.columnType(widened)
+		switch w.dialect {
+		case DialectPostgres:
+			fmt.Fprintf(&sb, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;\n", table, column, colType, column, colType)
+		case DialectMySQL:
+			fmt.Fprintf(&sb, "ALTER TABLE %s MODIFY COLUMN %s %s;\n", table, column, colType)
+		}
+	}
+	return sb.String()
+}
+
+// columnType returns the column type of a data type in the dialect.
+func (w *sqlWriter) columnType(typ parser.DataType) string {
+	switch w.dialect {
+	case DialectPostgres:
+		switch typ {
+		case parser.TypeInteger:
+			return "BIGINT"
+		case parser.TypeReal:
+			return "DOUBLE PRECISION"
+		case parser.TypeBoolean:
+			return "BOOLEAN"
+		case parser.TypeJSON:
+			return "JSONB"
+		}
+	case DialectMySQL:
+		switch typ {
+		case parser.TypeInteger:
+			return "BIGINT"
+		case parser.TypeReal:
+			return "DOUBLE"
+		case parser.TypeBoolean:
+			return "BOOLEAN"
+		case parser.TypeJSON:
+			return "JSON"
+		}
+	default:
+		switch typ {
+		case parser.TypeInteger, parser.TypeBoolean:
+			return "INTEGER"
+		case parser.TypeReal:
+			return "REAL"
+		}
+	}
+	return "TEXT"
+}
+
+// quoteIdent quotes an identifier for the dialect.
+func (w *sqlWriter) quoteIdent(name string) string {
+	if w.dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// literal returns a value as an SQL literal for a column of type typ.
+func (w *sqlWriter) literal(val any, typ parser.DataType) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if w.dialect == DialectSQLite {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strings.ToUpper(strconv.FormatBool(v))
+	case int64:
+		if typ == parser.TypeBoolean && w.dialect != DialectSQLite {
+			return strings.ToUpper(strconv.FormatBool(v != 0))
+		}
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return w.floatLiteral(v)
+	default:
+		return w.quoteString(cellText(v))
+	}
+}
+
+// floatLiteral returns a float as a literal. Infinities and NaN have no
+// literal in MySQL and are written as NULL.
+func (w *sqlWriter) floatLiteral(v float64) string {
+	if !math.IsNaN(v) && !math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	switch w.dialect {
+	case DialectPostgres:
+		switch {
+		case math.IsNaN(v):
+			return "'NaN'"
+		case v > 0:
+			return "'Infinity'"
+		default:
+			return "'-Infinity'"
+		}
+	case DialectSQLite:
+		switch {
+		case math.IsNaN(v):
+			return "NULL"
+		case v > 0:
+			return "9e999"
+		default:
+			return "-9e999"
+		}
+	}
+	return "NULL"
+}
+
+// quoteString quotes a string literal for the dialect. MySQL treats
+// backslashes in strings as escapes, so they are escaped too.
+func (w *sqlWriter) quoteString(s string) string {
+	if w.dialect != DialectMySQL {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case 0:
+			sb.WriteString(`\0`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case 0x1a:
+			sb.WriteString(`\Z`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tomlTable is the name of the array of tables holding the rows in TOML output.
+const tomlTable = "rows"
+
+var tomlBareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlWriter writes rows as a TOML array of tables, one row at a time. TOML
+// has no null, so NULL values are left out.
+type tomlWriter struct {
+	out    *bufio.Writer
+	layout *objectLayout
+	count  int
+}
+
+func (w *tomlWriter) Begin() error { return nil }
+
+func (w *tomlWriter) WriteRow(row []any) error {
+	var sb strings.Builder
+	if w.count > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("[[" + tomlTable + "]]\n")
+	for i, val := range row {
+		if w.layout.skip[i] || val == nil {
+			continue
+		}
+		sb.WriteString(tomlKey(w.layout.keys[i]) + " = ")
+		writeTOMLValue(&sb, val)
+		sb.WriteString("\n")
+	}
+	w.count++
+	_, err := w.out.WriteString(sb.String())
+	return err
+}
+
+func (w *tomlWriter) End() error { return w.out.Flush() }
+
+// tomlKey returns a key as a bare key if possible, or else as a quoted key.
+func tomlKey(key string) string {
+	if tomlBareKeyRegex.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// writeTOMLValue writes a value, with objects as inline tables in key order
+// and arrays as inline arrays. NULL values inside them are left out.
+func writeTOMLValue(sb *strings.Builder, val any) {
+	switch v := val.(type) {
+	case string:
+		sb.WriteString(tomlString(v))
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		sb.WriteString(tomlFloat(v))
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k, item := range v {
+			if item != nil {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		sb.WriteString("{")
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(" " + tomlKey(k) + " = ")
+			writeTOMLValue(sb, v[k])
+		}
+		if len(keys) > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("}")
+	case []any:
+		sb.WriteString("[")
+		n := 0
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			if n > 0 {
+				sb.WriteString(", ")
+			}
+			writeTOMLValue(sb, item)
+			n++
+		}
+		sb.WriteString("]")
+	default:
+		sb.WriteString(tomlString(fmt.Sprintf("%v", v)))
+	}
+}
+
+// tomlFloat formats a float, writing whole numbers such as those in nested
+// JSON values as integers.
+func tomlFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "nan"
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	case v == math.Trunc(v) && math.Abs(v) < 1<<53:
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// tomlString quotes a string as a TOML basic string.
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
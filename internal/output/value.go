@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/tidwall/gjson"
+
+	"github.com/kiki-ki/go-qo/internal/parser"
 )
 
 var multiSpaceRegex = regexp.MustCompile(`\s{2,}`)
@@ -42,6 +44,90 @@ func NormalizeValue(val any) any {
 	}
 }
 
+// NormalizeTypedValue is like NormalizeValue for a column whose source type is
+// known: booleans stored as integers become bools again, and only JSON columns
+// have their text parsed as JSON. Text of columns of unknown type (TypeNull),
+// such as expressions, is kept as it is.
+func NormalizeTypedValue(val any, typ parser.DataType) any {
+	switch typ {
+	case parser.TypeJSON:
+		return NormalizeValue(val)
+	case parser.TypeBoolean:
+		if n, ok := val.(int64); ok {
+			return n != 0
+		}
+	}
+	if b, ok := val.([]byte); ok {
+		return string(b)
+	}
+	if _, ok := val.(string); ok {
+		return val
+	}
+	return NormalizeValue(val)
+}
+
+// columnTypes returns a copy of the types of n columns, with TypeNull for
+// columns missing from types.
+func columnTypes(types []parser.DataType, n int) []parser.DataType {
+	columns := make([]parser.DataType, n)
+	for i := range columns {
+		columns[i] = parser.TypeNull
+		if i < len(types) {
+			columns[i] = types[i]
+		}
+	}
+	return columns
+}
+
+// inferTypes sets the type of columns of unknown type (TypeNull) from the
+// values of rows, and to TypeText for columns with only NULL values.
+func inferTypes(types []parser.DataType, rows [][]any) {
+	for i, typ := range types {
+		if typ != parser.TypeNull {
+			continue
+		}
+		for _, row := range rows {
+			typ = widenType(typ, valueType(row[i]))
+		}
+		if typ == parser.TypeNull {
+			typ = parser.TypeText
+		}
+		types[i] = typ
+	}
+}
+
+// valueType returns the type of a normalized value.
+func valueType(val any) parser.DataType {
+	switch val.(type) {
+	case nil:
+		return parser.TypeNull
+	case int, int64:
+		return parser.TypeInteger
+	case float64:
+		return parser.TypeReal
+	case bool:
+		return parser.TypeBoolean
+	case map[string]any, []any:
+		return parser.TypeJSON
+	default:
+		return parser.TypeText
+	}
+}
+
+// widenType returns a type that holds values of both types.
+func widenType(a, b parser.DataType) parser.DataType {
+	switch {
+	case a == b || b == parser.TypeNull:
+		return a
+	case a == parser.TypeNull:
+		return b
+	case (a == parser.TypeInteger || a == parser.TypeReal) && (b == parser.TypeInteger || b == parser.TypeReal):
+		return parser.TypeReal
+	default:
+		return parser.TypeText
+	}
+}
+
 // tryParseJSON attempts to parse a string as a JSON object or array.
 // Returns nil if the string is not valid JSON or is a primitive value.
 func tryParseJSON(s string) any {
@@ -1,9 +1,11 @@
 package output_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/kiki-ki/go-qo/internal/output"
+	"github.com/kiki-ki/go-qo/internal/parser"
 )
 
 func TestFormatValueForDisplay(t *testing.T) {
@@ -95,6 +97,35 @@ func TestNormalizeValue(t *testing.T) {
 	}
 }
 
+func TestNormalizeTypedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		typ   parser.DataType
+		want  any
+	}{
+		{"boolean true", int64(1), parser.TypeBoolean, true},
+		{"boolean false", int64(0), parser.TypeBoolean, false},
+		{"boolean null", nil, parser.TypeBoolean, nil},
+		{"integer", int64(1), parser.TypeInteger, int64(1)},
+		{"text holding JSON", `["a"]`, parser.TypeText, `["a"]`},
+		{"text bytes", []byte(`{"a":1}`), parser.TypeText, `{"a":1}`},
+		{"real whole", float64(2), parser.TypeReal, int64(2)},
+		{"JSON", `["a"]`, parser.TypeJSON, []any{"a"}},
+		{"unknown text holding JSON", `{"a":1}`, parser.TypeNull, `{"a":1}`},
+		{"unknown real whole", float64(2), parser.TypeNull, int64(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := output.NormalizeTypedValue(tt.input, tt.typ)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTypedValue(%v, %v) = %#v, want %#v", tt.input, tt.typ, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNormalizeValue_JSONParsing(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bufio"
+	"encoding/xml"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Default element names of XML output.
+const (
+	DefaultXMLRoot = "rows"
+	DefaultXMLRow  = "row"
+)
+
+// xmlItem is the element name of array elements in XML output.
+const xmlItem = "item"
+
+var xmlNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// IsValidXMLName reports whether name can be used as an XML element name.
+func IsValidXMLName(name string) bool {
+	return xmlNameRegex.MatchString(name)
+}
+
+// xmlWriter writes rows as XML, one row at a time: a root element holding an
+// element per row, with an element per column. Nested objects and arrays
+// become child elements, and NULL column values are left out.
+type xmlWriter struct {
+	out   *bufio.Writer
+	names []string // element name of each column
+	root  string
+	row   string
+}
+
+func newXMLWriter(out *bufio.Writer, columns []string, root, row string) *xmlWriter {
+	if root == "" {
+		root = DefaultXMLRoot
+	}
+	if row == "" {
+		row = DefaultXMLRow
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = xmlName(col)
+	}
+	return &xmlWriter{out: out, names: names, root: root, row: row}
+}
+
+func (w *xmlWriter) Begin() error {
+	_, err := w.out.WriteString(xml.Header + "<" + w.root + ">\n")
+	return err
+}
+
+func (w *xmlWriter) WriteRow(row []any) error {
+	var sb strings.Builder
+	sb.WriteString("  <" + w.row + ">\n")
+	for i, val := range row {
+		writeXMLElement(&sb, w.names[i], val, "    ")
+	}
+	sb.WriteString("  </" + w.row + ">\n")
+	_, err := w.out.WriteString(sb.String())
+	return err
+}
+
+func (w *xmlWriter) End() error {
+	if _, err := w.out.WriteString("</" + w.root + ">\n"); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// writeXMLElement writes a value as an element with the given name, indented
+// by indent. Objects get an element per key in key order, and arrays an item
+// element per value, empty for NULL.
+func writeXMLElement(sb *strings.Builder, name string, val any, indent string) {
+	switch v := val.(type) {
+	case nil:
+		return
+	case map[string]any:
+		sb.WriteString(indent + "<" + name + ">\n")
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			writeXMLElement(sb, xmlName(k), v[k], indent+"  ")
+		}
+		sb.WriteString(indent + "</" + name + ">\n")
+	case []any:
+		sb.WriteString(indent + "<" + name + ">\n")
+		for _, item := range v {
+			if item == nil {
+				// Keep the position of NULL items
+				sb.WriteString(indent + "  <" + xmlItem + "/>\n")
+				continue
+			}
+			writeXMLElement(sb, xmlItem, item, indent+"  ")
+		}
+		sb.WriteString(indent + "</" + name + ">\n")
+	default:
+		sb.WriteString(indent + "<" + name + ">")
+		_ = xml.EscapeText(sb, []byte(cellText(v)))
+		sb.WriteString("</" + name + ">\n")
+	}
+}
+
+// xmlName turns a column name or object key into a valid element name by
+// replacing invalid characters with underscores, e.g. "first name" becomes
+// "first_name" and "1st" becomes "_1st".
+func xmlName(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		nameChar := r >= '0' && r <= '9' || r == '.' || r == '-'
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '_'):
+			sb.WriteRune(r)
+		case nameChar && i == 0:
+			sb.WriteString("_" + string(r))
+		case nameChar:
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlWriter writes rows as a YAML list of mappings, one row at a time, with
+// keys in column order.
+type yamlWriter struct {
+	out    *bufio.Writer
+	layout *objectLayout
+	count  int
+}
+
+func (w *yamlWriter) Begin() error { return nil }
+
+func (w *yamlWriter) WriteRow(row []any) error {
+	item := &yaml.Node{Kind: yaml.MappingNode}
+	for i, val := range row {
+		if w.layout.skip[i] {
+			continue
+		}
+		key, value := &yaml.Node{}, &yaml.Node{}
+		if err := key.Encode(w.layout.keys[i]); err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+		if err := value.Encode(val); err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+		item.Content = append(item.Content, key, value)
+	}
+
+	// Each row is encoded as a list of one item, so that rows written one
+	// after the other form a single list
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{item}}); err != nil {
+		return fmt.Errorf("failed to encode row: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode row: %w", err)
+	}
+	w.count++
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+func (w *yamlWriter) End() error {
+	if w.count == 0 {
+		if _, err := w.out.WriteString("[]\n"); err != nil {
+			return err
+		}
+	}
+	return w.out.Flush()
+}
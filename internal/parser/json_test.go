@@ -237,19 +237,23 @@ func TestParsedData_ColumnNames(t *testing.T) {
 
 func TestDataType_String(t *testing.T) {
 	tests := []struct {
-		dt   parser.DataType
-		want string
+		dt       parser.DataType
+		want     string
+		wantName string
 	}{
-		{parser.TypeText, "TEXT"},
-		{parser.TypeInteger, "INTEGER"},
-		{parser.TypeReal, "REAL"},
-		{parser.TypeBoolean, "INTEGER"},
-		{parser.TypeJSON, "TEXT"},
-		{parser.TypeNull, "TEXT"},
+		{parser.TypeText, "TEXT", "TEXT"},
+		{parser.TypeInteger, "INTEGER", "INTEGER"},
+		{parser.TypeReal, "REAL", "REAL"},
+		{parser.TypeBoolean, "INTEGER", "BOOLEAN"},
+		{parser.TypeJSON, "TEXT", "JSON"},
+		{parser.TypeNull, "TEXT", "NULL"},
 	}
 	for _, tt := range tests {
 		if got := tt.dt.String(); got != tt.want {
 			t.Errorf("DataType(%d).String() = %q, want %q", tt.dt, got, tt.want)
 		}
+		if got := tt.dt.Name(); got != tt.wantName {
+			t.Errorf("DataType(%d).Name() = %q, want %q", tt.dt, got, tt.wantName)
+		}
 	}
 }
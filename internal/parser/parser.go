@@ -32,6 +32,25 @@ func (dt DataType) String() string {
 	}
 }
 
+// Name returns the name of the inferred type, which unlike String
+// distinguishes booleans, JSON and all-null columns.
+func (dt DataType) Name() string {
+	switch dt {
+	case TypeInteger:
+		return "INTEGER"
+	case TypeReal:
+		return "REAL"
+	case TypeBoolean:
+		return "BOOLEAN"
+	case TypeJSON:
+		return "JSON"
+	case TypeNull:
+		return "NULL"
+	default:
+		return "TEXT"
+	}
+}
+
 // Column represents a table column with its name and type.
 type Column struct {
 	Name string
@@ -0,0 +1,212 @@
+// Package sqllex provides a lightweight tokenizer for SQLite SQL text.
+package sqllex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind represents the kind of a token.
+type Kind int
+
+const (
+	Word        Kind = iota // keyword or bare identifier
+	QuotedIdent             // "ident", `ident` or [ident]
+	String                  // 'string literal'
+	Number                  // numeric literal
+	Param                   // ?, ?NNN, :name, @name or $name
+	Operator                // operators and punctuation
+)
+
+// Token is a lexical token with its byte offsets in the source text.
+type Token struct {
+	Kind Kind
+	Text string // raw text as it appears in the source
+	Pos  int    // byte offset of the first character
+	End  int    // byte offset just past the last character
+}
+
+// Is reports whether the token is the given keyword (case-insensitive) or operator.
+func (t Token) Is(text string) bool {
+	if t.Kind == Word {
+		return strings.EqualFold(t.Text, text)
+	}
+	return t.Kind == Operator && t.Text == text
+}
+
+// IsIdent reports whether the token can name a table or column.
+func (t Token) IsIdent() bool {
+	return t.Kind == Word || t.Kind == QuotedIdent
+}
+
+// Ident returns the identifier name with quotes removed.
+func (t Token) Ident() string {
+	if t.Kind != QuotedIdent || len(t.Text) < 2 {
+		return t.Text
+	}
+	inner := t.Text[1 : len(t.Text)-1]
+	switch t.Text[0] {
+	case '"':
+		return strings.ReplaceAll(inner, `""`, `"`)
+	case '`':
+		return strings.ReplaceAll(inner, "``", "`")
+	default:
+		return inner
+	}
+}
+
+// multiCharOperators lists operators longer than one character, longest first.
+var multiCharOperators = []string{"->>", "||", "<=", ">=", "==", "!=", "<>", "<<", ">>", "->"}
+
+// Tokenize splits SQL text into tokens. Whitespace and comments are skipped.
+// Unterminated strings, identifiers and comments extend to the end of the input.
+func Tokenize(src string) []Token {
+	var tokens []Token
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+
+		switch {
+		case isSpace(c):
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--"):
+			i = indexFrom(src, i, "\n", 0)
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			i = indexFrom(src, i+2, "*/", 2)
+			continue
+		case c == '\'':
+			i = scanQuoted(src, i, '\'')
+			tokens = append(tokens, Token{Kind: String, Text: src[start:i], Pos: start, End: i})
+		case c == '"' || c == '`':
+			i = scanQuoted(src, i, c)
+			tokens = append(tokens, Token{Kind: QuotedIdent, Text: src[start:i], Pos: start, End: i})
+		case c == '[':
+			i = indexFrom(src, i, "]", 1)
+			tokens = append(tokens, Token{Kind: QuotedIdent, Text: src[start:i], Pos: start, End: i})
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			i = scanNumber(src, i)
+			tokens = append(tokens, Token{Kind: Number, Text: src[start:i], Pos: start, End: i})
+		case c == '?' || ((c == ':' || c == '@' || c == '$') && i+1 < len(src) && isWordStart(src, i+1)):
+			i++
+			for i < len(src) && isWordByte(src, i) {
+				i += runeLen(src, i)
+			}
+			tokens = append(tokens, Token{Kind: Param, Text: src[start:i], Pos: start, End: i})
+		case isWordStart(src, i):
+			for i < len(src) && isWordByte(src, i) {
+				i += runeLen(src, i)
+			}
+			tokens = append(tokens, Token{Kind: Word, Text: src[start:i], Pos: start, End: i})
+		default:
+			i += operatorLen(src, i)
+			tokens = append(tokens, Token{Kind: Operator, Text: src[start:i], Pos: start, End: i})
+		}
+	}
+	return tokens
+}
+
+// indexFrom returns the offset just past the first occurrence of sep at or
+// after from (skipping skip bytes first), or len(src) if sep is not found.
+func indexFrom(src string, from int, sep string, skip int) int {
+	from += skip
+	if from > len(src) {
+		return len(src)
+	}
+	if idx := strings.Index(src[from:], sep); idx >= 0 {
+		return from + idx + len(sep)
+	}
+	return len(src)
+}
+
+// scanQuoted returns the offset just past a quoted token starting at i.
+// A doubled quote character is an escaped quote.
+func scanQuoted(src string, i int, quote byte) int {
+	for i++; i < len(src); i++ {
+		if src[i] != quote {
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(src)
+}
+
+// scanNumber returns the offset just past a numeric literal starting at i.
+func scanNumber(src string, i int) int {
+	if strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0X") {
+		i += 2
+		for i < len(src) && isHexDigit(src[i]) {
+			i++
+		}
+		return i
+	}
+	for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == '_') {
+		i++
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && isDigit(src[j]) {
+			i = j
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// operatorLen returns the length of the operator or punctuation at i.
+func operatorLen(src string, i int) int {
+	for _, op := range multiCharOperators {
+		if strings.HasPrefix(src[i:], op) {
+			return len(op)
+		}
+	}
+	return runeLen(src, i)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// isWordStart reports whether the character at i can start a bare identifier.
+func isWordStart(src string, i int) bool {
+	c := src[i]
+	if c < utf8.RuneSelf {
+		return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z')
+	}
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return unicode.IsLetter(r)
+}
+
+// isWordByte reports whether the character at i can continue a bare identifier.
+func isWordByte(src string, i int) bool {
+	c := src[i]
+	if c < utf8.RuneSelf {
+		return isDigit(c) || c == '$' || isWordStart(src, i)
+	}
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func runeLen(src string, i int) int {
+	_, size := utf8.DecodeRuneInString(src[i:])
+	return size
+}
@@ -0,0 +1,116 @@
+package sqllex_test
+
+import (
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+func TestTokenize(t *testing.T) {
+	type tok struct {
+		kind sqllex.Kind
+		text string
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want []tok
+	}{
+		{
+			name: "simple select",
+			src:  "SELECT id, name FROM users WHERE id >= 10",
+			want: []tok{
+				{sqllex.Word, "SELECT"}, {sqllex.Word, "id"}, {sqllex.Operator, ","}, {sqllex.Word, "name"},
+				{sqllex.Word, "FROM"}, {sqllex.Word, "users"}, {sqllex.Word, "WHERE"}, {sqllex.Word, "id"},
+				{sqllex.Operator, ">="}, {sqllex.Number, "10"},
+			},
+		},
+		{
+			name: "strings and quoted identifiers",
+			src:  `SELECT 'it''s; here', "a ""b""", ` + "`c`, [d e]",
+			want: []tok{
+				{sqllex.Word, "SELECT"}, {sqllex.String, "'it''s; here'"}, {sqllex.Operator, ","},
+				{sqllex.QuotedIdent, `"a ""b"""`}, {sqllex.Operator, ","}, {sqllex.QuotedIdent, "`c`"},
+				{sqllex.Operator, ","}, {sqllex.QuotedIdent, "[d e]"},
+			},
+		},
+		{
+			name: "comments are skipped",
+			src:  "SELECT 1 -- trailing; comment\n/* block; */ ;",
+			want: []tok{{sqllex.Word, "SELECT"}, {sqllex.Number, "1"}, {sqllex.Operator, ";"}},
+		},
+		{
+			name: "parameters",
+			src:  "? ?2 :id @name $v",
+			want: []tok{
+				{sqllex.Param, "?"}, {sqllex.Param, "?2"}, {sqllex.Param, ":id"}, {sqllex.Param, "@name"}, {sqllex.Param, "$v"},
+			},
+		},
+		{
+			name: "numbers and operators",
+			src:  "1.5e3 0xFF .5 a->>'$.x' || b",
+			want: []tok{
+				{sqllex.Number, "1.5e3"}, {sqllex.Number, "0xFF"}, {sqllex.Number, ".5"}, {sqllex.Word, "a"},
+				{sqllex.Operator, "->>"}, {sqllex.String, "'$.x'"}, {sqllex.Operator, "||"}, {sqllex.Word, "b"},
+			},
+		},
+		{
+			name: "unterminated string",
+			src:  "SELECT 'abc",
+			want: []tok{{sqllex.Word, "SELECT"}, {sqllex.String, "'abc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sqllex.Tokenize(tt.src)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tokens %v, want %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got[i].Kind != w.kind || got[i].Text != w.text {
+					t.Errorf("token %d = (%d, %q), want (%d, %q)", i, got[i].Kind, got[i].Text, w.kind, w.text)
+				}
+				if tt.src[got[i].Pos:got[i].End] != got[i].Text {
+					t.Errorf("token %d offsets do not match text %q", i, got[i].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestToken_Ident(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"users", "users"},
+		{`"my ""table"""`, `my "table"`},
+		{"`my table`", "my table"},
+		{"[my table]", "my table"},
+	}
+
+	for _, tt := range tests {
+		tokens := sqllex.Tokenize(tt.src)
+		if len(tokens) != 1 {
+			t.Fatalf("expected 1 token for %q, got %d", tt.src, len(tokens))
+		}
+		if got := tokens[0].Ident(); got != tt.want {
+			t.Errorf("Ident(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestToken_Is(t *testing.T) {
+	tokens := sqllex.Tokenize("select = 'select'")
+	if !tokens[0].Is("SELECT") {
+		t.Error("expected keyword match to be case-insensitive")
+	}
+	if !tokens[1].Is("=") {
+		t.Error("expected operator match")
+	}
+	if tokens[2].Is("select") {
+		t.Error("string literal should not match a keyword")
+	}
+}
@@ -0,0 +1,43 @@
+package sqllex
+
+import "strings"
+
+// queryPragmas lists pragmas whose argument selects what to report rather
+// than a value to set, e.g. PRAGMA table_info(t).
+var queryPragmas = map[string]bool{
+	"foreign_key_check": true,
+	"foreign_key_list":  true,
+	"index_info":        true,
+	"index_list":        true,
+	"index_xinfo":       true,
+	"integrity_check":   true,
+	"quick_check":       true,
+	"table_info":        true,
+	"table_list":        true,
+	"table_xinfo":       true,
+	"wal_checkpoint":    true,
+}
+
+// IsPragmaAssignment reports whether the tokens of a PRAGMA statement set a
+// value, as in PRAGMA user_version = 3 or PRAGMA cache_size(100), rather than
+// query one.
+func IsPragmaAssignment(tokens []Token) bool {
+	if len(tokens) == 0 || !tokens[0].Is("pragma") {
+		return false
+	}
+	i := 1
+	if i+1 < len(tokens) && tokens[i+1].Is(".") { // schema.name
+		i += 2
+	}
+	if i+1 >= len(tokens) || !tokens[i].IsIdent() {
+		return false
+	}
+	switch next := tokens[i+1]; {
+	case next.Is("="):
+		return true
+	case next.Is("("):
+		return !queryPragmas[strings.ToLower(tokens[i].Ident())]
+	default:
+		return false
+	}
+}
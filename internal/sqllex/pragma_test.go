@@ -0,0 +1,27 @@
+package sqllex_test
+
+import (
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+func TestIsPragmaAssignment(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"PRAGMA user_version = 3", true},
+		{"PRAGMA query_only=OFF", true},
+		{"pragma main.cache_size(100)", true},
+		{"PRAGMA user_version", false},
+		{"PRAGMA table_info(t)", false},
+		{"PRAGMA main.index_list('t')", false},
+		{"SELECT 1 = 1", false},
+	}
+	for _, tt := range tests {
+		if got := sqllex.IsPragmaAssignment(sqllex.Tokenize(tt.stmt)); got != tt.want {
+			t.Errorf("IsPragmaAssignment(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package sqllex
+
+// Split splits SQL text into statements at semicolons. Semicolons inside
+// strings, comments and trigger bodies (CREATE TRIGGER ... BEGIN ... END)
+// do not end a statement. Statements are returned without the terminating
+// semicolon, and empty statements are dropped.
+func Split(src string) []string {
+	var stmts []string
+	tokens := Tokenize(src)
+
+	start := 0
+	inBody := false // inside the BEGIN ... END of a trigger
+	cases := 0      // open CASE expressions inside a trigger body
+	for i, tok := range tokens {
+		switch {
+		case inBody && tok.Is("case"):
+			cases++
+		case inBody && tok.Is("end"):
+			if cases > 0 {
+				cases--
+			} else {
+				inBody = false
+			}
+		case !inBody && tok.Is("begin") && isCreateTrigger(tokens[start:i]):
+			inBody = true
+		case !inBody && tok.Is(";"):
+			if i > start {
+				stmts = append(stmts, src[tokens[start].Pos:tokens[i-1].End])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, src[tokens[start].Pos:tokens[len(tokens)-1].End])
+	}
+	return stmts
+}
+
+// isCreateTrigger reports whether the tokens start a CREATE [TEMP] TRIGGER statement.
+func isCreateTrigger(tokens []Token) bool {
+	if len(tokens) < 2 || !tokens[0].Is("create") {
+		return false
+	}
+	if tokens[1].Is("temp") || tokens[1].Is("temporary") {
+		tokens = tokens[1:]
+	}
+	return len(tokens) >= 2 && tokens[1].Is("trigger")
+}
@@ -0,0 +1,61 @@
+package sqllex_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kiki-ki/go-qo/internal/sqllex"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "single statement",
+			src:  "SELECT 1",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "multiple statements",
+			src:  "CREATE VIEW v AS SELECT 1;\nUPDATE t SET x = 1;\n\nSELECT * FROM v;",
+			want: []string{"CREATE VIEW v AS SELECT 1", "UPDATE t SET x = 1", "SELECT * FROM v"},
+		},
+		{
+			name: "semicolons in strings and comments",
+			src:  "SELECT 'a;b', \"c;d\" -- e;f\n; /* g; */ SELECT 2",
+			want: []string{"SELECT 'a;b', \"c;d\"", "SELECT 2"},
+		},
+		{
+			name: "empty statements",
+			src:  ";; -- only a comment\n;",
+			want: nil,
+		},
+		{
+			name: "trigger body",
+			src: `CREATE TEMP TRIGGER tr AFTER INSERT ON t BEGIN
+				UPDATE t SET x = CASE WHEN new.x > 0 THEN 1 ELSE 0 END WHERE rowid = new.rowid;
+				INSERT INTO log VALUES (new.x);
+			END; SELECT 1`,
+			want: []string{`CREATE TEMP TRIGGER tr AFTER INSERT ON t BEGIN
+				UPDATE t SET x = CASE WHEN new.x > 0 THEN 1 ELSE 0 END WHERE rowid = new.rowid;
+				INSERT INTO log VALUES (new.x);
+			END`, "SELECT 1"},
+		},
+		{
+			name: "transaction",
+			src:  "BEGIN; INSERT INTO t VALUES (1); COMMIT",
+			want: []string{"BEGIN", "INSERT INTO t VALUES (1)", "COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqllex.Split(tt.src); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
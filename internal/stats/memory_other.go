@@ -0,0 +1,13 @@
+//go:build !unix
+
+package stats
+
+import "runtime"
+
+// peakMemory returns the memory obtained from the OS by the Go runtime, as
+// the peak resident set size is not available on this platform.
+func peakMemory() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Sys
+}
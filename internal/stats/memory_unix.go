@@ -0,0 +1,21 @@
+//go:build unix
+
+package stats
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns the maximum resident set size of the process.
+func peakMemory() uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	// Linux and most BSDs report kilobytes, macOS reports bytes
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return uint64(usage.Maxrss)
+	}
+	return uint64(usage.Maxrss) * 1024
+}
@@ -0,0 +1,177 @@
+// Package stats collects timings and counters of a qo run.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Stats collects the time spent in each phase of a run along with the input
+// read and the rows loaded. Methods are safe for concurrent use, and a nil
+// *Stats records nothing, so instrumented code need not check whether stats
+// are enabled.
+type Stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	load      time.Duration
+	parse     time.Duration
+	insert    time.Duration
+	query     time.Duration
+	bytesRead int64
+	tables    []Table
+}
+
+// Table holds the rows inserted into a table and the time it took.
+type Table struct {
+	Name   string
+	Rows   int64
+	Insert time.Duration
+}
+
+// New returns a Stats measuring the total time from now.
+func New() *Stats {
+	return &Stats{start: time.Now()}
+}
+
+// AddParse records time spent reading and parsing size bytes of input.
+func (s *Stats) AddParse(d time.Duration, size int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parse += d
+	s.bytesRead += size
+}
+
+// AddInsert records rows inserted into a table.
+func (s *Stats) AddInsert(table string, rows int64, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insert += d
+	s.tables = append(s.tables, Table{Name: table, Rows: rows, Insert: d})
+}
+
+// SetLoad records the wall time of loading all inputs. Files are parsed
+// concurrently, so it can be less than the sum of parse and insert times.
+func (s *Stats) SetLoad(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load = d
+}
+
+// AddQuery records time spent running statements and printing their results.
+func (s *Stats) AddQuery(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.query += d
+}
+
+// Report is a snapshot of the collected statistics.
+type Report struct {
+	Parse      time.Duration
+	Insert     time.Duration
+	Load       time.Duration
+	Query      time.Duration
+	Total      time.Duration
+	BytesRead  int64
+	Tables     []Table
+	PeakMemory uint64 // peak resident memory of the process in bytes, 0 if unknown
+}
+
+// Report returns the statistics collected so far.
+func (s *Stats) Report() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Report{
+		Parse:      s.parse,
+		Insert:     s.insert,
+		Load:       s.load,
+		Query:      s.query,
+		Total:      time.Since(s.start),
+		BytesRead:  s.bytesRead,
+		Tables:     append([]Table(nil), s.tables...),
+		PeakMemory: peakMemory(),
+	}
+}
+
+// WriteText writes the report as aligned text.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PHASE\tTIME")
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"parse", r.Parse},
+		{"insert", r.Insert},
+		{"load", r.Load},
+		{"query", r.Query},
+		{"total", r.Total},
+	} {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", phase.name, phase.d.Round(time.Microsecond))
+	}
+
+	if len(r.Tables) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nTABLE\tROWS\tINSERT")
+		for _, t := range r.Tables {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", t.Name, t.Rows, t.Insert.Round(time.Microsecond))
+		}
+	}
+
+	_, _ = fmt.Fprintf(tw, "\nbytes read\t%d\n", r.BytesRead)
+	if r.PeakMemory > 0 {
+		_, _ = fmt.Fprintf(tw, "peak memory\t%.1f MiB\n", float64(r.PeakMemory)/(1<<20))
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as a JSON object with times in milliseconds.
+func (r *Report) WriteJSON(w io.Writer) error {
+	type table struct {
+		Name     string  `json:"name"`
+		Rows     int64   `json:"rows"`
+		InsertMS float64 `json:"insert_ms"`
+	}
+	tables := make([]table, len(r.Tables))
+	for i, t := range r.Tables {
+		tables[i] = table{Name: t.Name, Rows: t.Rows, InsertMS: milliseconds(t.Insert)}
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		ParseMS    float64 `json:"parse_ms"`
+		InsertMS   float64 `json:"insert_ms"`
+		LoadMS     float64 `json:"load_ms"`
+		QueryMS    float64 `json:"query_ms"`
+		TotalMS    float64 `json:"total_ms"`
+		BytesRead  int64   `json:"bytes_read"`
+		Tables     []table `json:"tables"`
+		PeakMemory uint64  `json:"peak_memory_bytes,omitempty"`
+	}{
+		ParseMS:    milliseconds(r.Parse),
+		InsertMS:   milliseconds(r.Insert),
+		LoadMS:     milliseconds(r.Load),
+		QueryMS:    milliseconds(r.Query),
+		TotalMS:    milliseconds(r.Total),
+		BytesRead:  r.BytesRead,
+		Tables:     tables,
+		PeakMemory: r.PeakMemory,
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
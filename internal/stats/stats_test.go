@@ -0,0 +1,69 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiki-ki/go-qo/internal/stats"
+)
+
+func TestStats_Nil(t *testing.T) {
+	var s *stats.Stats
+	// Recording on nil stats is a no-op
+	s.AddParse(time.Second, 10)
+	s.AddInsert("t", 1, time.Second)
+	s.SetLoad(time.Second)
+	s.AddQuery(time.Second)
+}
+
+func TestStats_Report(t *testing.T) {
+	s := stats.New()
+	s.AddParse(2*time.Millisecond, 100)
+	s.AddParse(3*time.Millisecond, 50)
+	s.AddInsert("users", 10, 4*time.Millisecond)
+	s.AddInsert("orders", 20, time.Millisecond)
+	s.SetLoad(8 * time.Millisecond)
+	s.AddQuery(1500 * time.Microsecond)
+
+	report := s.Report()
+	if report.Parse != 5*time.Millisecond || report.Insert != 5*time.Millisecond || report.BytesRead != 150 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteText(&buf); err != nil {
+			t.Fatalf("WriteText failed: %v", err)
+		}
+		for _, want := range []string{"parse   5ms", "load    8ms", "query   1.5ms", "users   10", "orders  20", "bytes read"} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("expected %q in output:\n%s", want, buf.String())
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteJSON(&buf); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		var got struct {
+			ParseMS   float64 `json:"parse_ms"`
+			QueryMS   float64 `json:"query_ms"`
+			BytesRead int64   `json:"bytes_read"`
+			Tables    []struct {
+				Name string `json:"name"`
+				Rows int64  `json:"rows"`
+			} `json:"tables"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+		}
+		if got.ParseMS != 5 || got.QueryMS != 1.5 || got.BytesRead != 150 || len(got.Tables) != 2 || got.Tables[1].Rows != 20 {
+			t.Errorf("unexpected JSON: %s", buf.String())
+		}
+	})
+}
@@ -1,9 +1,16 @@
 package ui
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kiki-ki/go-qo/internal/db"
 )
 
 const debounceDelay = 150 * time.Millisecond
@@ -26,36 +33,147 @@ func (m *Model) scheduleQueryExecution() tea.Cmd {
 	})
 }
 
-// handleDebounceMsg executes the query if it matches the pending query.
-func (m *Model) handleDebounceMsg(msg debounceMsg) {
+// queryResultMsg carries the result of a query run in the background.
+type queryResultMsg struct {
+	id         int
+	query      string
+	allowWrite bool // the query ran without the read-only guard
+	columns    []table.Column
+	rows       []table.Row
+	err        error
+}
+
+// handleDebounceMsg starts the query if it matches the pending query.
+func (m *Model) handleDebounceMsg(msg debounceMsg) tea.Cmd {
 	if msg.query == m.pendingQuery && msg.query != m.lastExecQuery {
-		m.executeQuery()
 		m.lastExecQuery = m.pendingQuery
+		return m.executeQuery(false)
 	}
+	return nil
 }
 
-// executeQuery runs the current query and updates the table.
-func (m *Model) executeQuery() {
+// executeQuery starts the current query in the background, canceling any
+// query still running. The result arrives as a queryResultMsg.
+// Unless allowWrite is set, statements that modify the database are not run
+// but held for confirmation.
+func (m *Model) executeQuery(allowWrite bool) tea.Cmd {
 	query := m.textInput.Value()
 	if query == "" {
-		return
+		return nil
+	}
+	m.cancelQuery()
+	m.pendingWrite = ""
+	m.status = "Running query... (Esc to cancel)"
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if m.options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), m.options.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	m.execID++
+	m.cancel = cancel
+
+	id, sqlDB, opts := m.execID, m.db, m.options
+	readOnly := opts.ReadOnly || !allowWrite
+	return func() tea.Msg {
+		msg := queryResultMsg{id: id, query: query, allowWrite: !readOnly}
+		msg.columns, msg.rows, msg.err = runQuery(ctx, sqlDB, query, opts, readOnly)
+		return msg
 	}
+}
 
-	rows, err := m.db.Query(query)
+// runQuery prepares and runs a query, returning its result as table data.
+// With readOnly, the query runs in query-only mode and fails if it would
+// modify the database, including statements that query-only mode misses.
+func runQuery(ctx context.Context, sqlDB *sql.DB, query string, opts *Options, readOnly bool) ([]table.Column, []table.Row, error) {
+	if readOnly {
+		if err := db.CheckReadOnly(query); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.Prepare != nil {
+		prepared, err := opts.Prepare(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = prepared
+	}
+
+	conn, err := sqlDB.Conn(ctx)
 	if err != nil {
-		m.err = err
-		return
+		return nil, nil, err
 	}
-	defer func() { _ = rows.Close() }()
+	defer func() { _ = conn.Close() }()
 
-	cols, tableRows, err := SQLRowsToTableData(rows)
+	if readOnly {
+		if _, err := conn.ExecContext(ctx, db.QueryOnlyPragma(true)); err != nil {
+			return nil, nil, err
+		}
+		// Restore the connection for other users such as saving and CLI output
+		defer func() { _, _ = conn.ExecContext(context.Background(), db.QueryOnlyPragma(false)) }()
+	}
+
+	rows, err := conn.QueryContext(ctx, query, opts.Args...)
 	if err != nil {
-		m.err = err
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return SQLRowsToTableData(rows)
+}
+
+// handleQueryResult shows the result of the latest query. Results of
+// queries that were superseded are discarded.
+func (m *Model) handleQueryResult(msg queryResultMsg) {
+	if msg.id != m.execID || m.cancel == nil {
 		return
 	}
+	timedOut := errors.Is(msg.err, context.DeadlineExceeded)
+	canceled := m.canceled
+	m.cancelQuery()
+	m.status = ""
 
-	m.tableState.SetData(cols, tableRows)
-	m.table.SetCursor(0)
-	m.syncTableView(true)
-	m.err = nil
+	switch {
+	case canceled:
+		m.err = nil
+		m.status = "Query canceled"
+	case timedOut:
+		m.err = fmt.Errorf("query timed out after %s", m.options.Timeout)
+	case !msg.allowWrite && db.IsReadOnlyError(msg.err) && m.options.ReadOnly:
+		m.err = errors.New("read-only mode: statement modifies the database")
+	case !msg.allowWrite && db.IsReadOnlyError(msg.err):
+		m.err = nil
+		m.pendingWrite = msg.query
+		m.status = "Statement modifies the database (Ctrl+R to run it)"
+	case msg.err != nil:
+		m.err = msg.err
+	default:
+		m.tableState.SetData(msg.columns, msg.rows)
+		m.table.SetCursor(0)
+		m.syncTableView(true)
+		m.err = nil
+		if msg.allowWrite {
+			m.status = "Statement executed"
+		}
+	}
+}
+
+// interruptQuery cancels the query in flight; its result reports the cancellation.
+func (m *Model) interruptQuery() {
+	if m.cancel != nil {
+		m.cancel()
+		m.canceled = true
+		m.status = "Canceling query..."
+	}
+}
+
+// cancelQuery cancels the query in flight, if any, and forgets it.
+func (m *Model) cancelQuery() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	m.canceled = false
 }
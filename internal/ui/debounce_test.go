@@ -31,7 +31,7 @@ func TestNewDebounceMsg(t *testing.T) {
 
 func TestHandleDebounceMsg_ExecutesMatchingQuery(t *testing.T) {
 	db := setupTestDB(t)
-	m := NewModel(db, []string{"test"})
+	m := NewModel(db, []string{"test"}, nil)
 
 	// Set pending query
 	m.pendingQuery = "SELECT * FROM test"
@@ -47,7 +47,7 @@ func TestHandleDebounceMsg_ExecutesMatchingQuery(t *testing.T) {
 
 func TestHandleDebounceMsg_SkipsNonMatchingQuery(t *testing.T) {
 	db := setupTestDB(t)
-	m := NewModel(db, []string{"test"})
+	m := NewModel(db, []string{"test"}, nil)
 
 	// Set pending query to something different
 	m.pendingQuery = "SELECT * FROM test WHERE id = 1"
@@ -63,7 +63,7 @@ func TestHandleDebounceMsg_SkipsNonMatchingQuery(t *testing.T) {
 
 func TestHandleDebounceMsg_SkipsDuplicateExecution(t *testing.T) {
 	db := setupTestDB(t)
-	m := NewModel(db, []string{"test"})
+	m := NewModel(db, []string{"test"}, nil)
 
 	query := "SELECT * FROM test"
 	m.pendingQuery = query
@@ -82,7 +82,7 @@ func TestHandleDebounceMsg_SkipsDuplicateExecution(t *testing.T) {
 
 func TestScheduleQueryExecution(t *testing.T) {
 	db := setupTestDB(t)
-	m := NewModel(db, []string{"test"})
+	m := NewModel(db, []string{"test"}, nil)
 	m.pendingQuery = "SELECT * FROM test"
 
 	cmd := m.scheduleQueryExecution()
@@ -36,6 +36,7 @@ func (m Mode) CommandsHint() string {
 var (
 	baseModeCommands = []modeCommand{
 		{key: "Tab", message: "switch mode"},
+		{key: "Ctrl+S", message: "save db"},
 		{key: "Esc", message: "quit"},
 	}
 	queryModeCommands = slices.Concat(baseModeCommands, []modeCommand{
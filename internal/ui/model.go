@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,6 +18,24 @@ type Result struct {
 	Query string
 }
 
+// Options configures the UI.
+type Options struct {
+	SavePath string                  // destination of the save action (Ctrl+S); empty disables saving
+	Save     func(path string) error // writes the database to a file; nil disables saving
+
+	// Prepare is called with each query before it runs, e.g. to create indexes,
+	// and returns the query to execute. It gets the query's context, so canceling
+	// the query interrupts it too. Errors are shown like query errors.
+	Prepare func(ctx context.Context, query string) (string, error)
+
+	Args    []any         // arguments bound to query placeholders
+	Timeout time.Duration // aborts queries running longer than this; 0 for no limit
+
+	// ReadOnly refuses statements that modify the database. Otherwise they are
+	// held until confirmed, as queries run while typing.
+	ReadOnly bool
+}
+
 // Model represents the UI application state.
 type Model struct {
 	db         *sql.DB
@@ -26,6 +46,8 @@ type Model struct {
 	width      int
 	height     int
 	tableNames []string
+	options    *Options
+	status     string // informational message shown in place of an error
 
 	// Table data state
 	tableState *TableState
@@ -34,6 +56,14 @@ type Model struct {
 	pendingQuery  string
 	lastExecQuery string
 
+	// Query execution state
+	execID   int                // identifies the latest query started
+	cancel   context.CancelFunc // cancels the query in flight; nil when idle
+	canceled bool               // the query in flight was interrupted by the user
+	saving   bool               // a save started with Ctrl+S is in progress
+
+	pendingWrite string // statement that modifies the database, awaiting confirmation
+
 	// Result when exiting
 	result *Result
 }
@@ -49,7 +79,10 @@ func (m Model) PendingQuery() string {
 }
 
 // NewModel creates a new UI model.
-func NewModel(db *sql.DB, tableNames []string) Model {
+func NewModel(db *sql.DB, tableNames []string, options *Options) Model {
+	if options == nil {
+		options = &Options{}
+	}
 	ti := newTextInput(tableNames)
 	t := newTable()
 
@@ -60,6 +93,7 @@ func NewModel(db *sql.DB, tableNames []string) Model {
 		textInput:    ti,
 		tableState:   NewTableState(),
 		tableNames:   tableNames,
+		options:      options,
 		pendingQuery: ti.Value(),
 	}
 }
@@ -123,7 +157,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.handleWindowResize(msg)
 	case debounceMsg:
-		m.handleDebounceMsg(msg)
+		cmds = append(cmds, m.handleDebounceMsg(msg))
+	case queryResultMsg:
+		m.handleQueryResult(msg)
+	case saveDoneMsg:
+		m.handleSaveDone(msg)
 	case tea.KeyMsg:
 		if cmd, quit := m.handleKeyMsg(msg); quit {
 			return m, tea.Quit
@@ -177,17 +215,32 @@ func (m *Model) handleWindowResize(msg tea.WindowSizeMsg) {
 func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Cmd, bool) {
 	switch msg.Type {
 	case tea.KeyCtrlC, tea.KeyEsc:
+		// Interrupt a running query first; quit when idle or pressed again while canceling
+		if m.cancel != nil && !m.canceled {
+			m.interruptQuery()
+			return nil, false
+		}
 		return nil, true
 
 	case tea.KeyEnter:
 		if m.mode == ModeQuery && m.textInput.Value() != "" {
+			m.cancelQuery()
 			m.result = &Result{Query: m.textInput.Value()}
 			return nil, true
 		}
 
+	case tea.KeyCtrlR:
+		// Run a statement held back because it modifies the database
+		if m.mode == ModeQuery && m.pendingWrite != "" && m.pendingWrite == m.textInput.Value() {
+			return m.executeQuery(true), false
+		}
+
 	case tea.KeyTab:
 		return m.toggleMode(), false
 
+	case tea.KeyCtrlS:
+		return m.saveDatabase(), false
+
 	case tea.KeyLeft, tea.KeyRight, tea.KeyRunes:
 		if m.mode == ModeTable {
 			m.handleTableNavigation(msg)
@@ -196,6 +249,48 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Cmd, bool) {
 	return nil, false
 }
 
+// saveDoneMsg carries the outcome of a save run in the background.
+type saveDoneMsg struct {
+	path string
+	err  error
+}
+
+// saveDatabase starts writing the database to the configured save path in the
+// background; the outcome arrives as a saveDoneMsg. Saving waits for the
+// database connection, so it is refused while a query is running.
+func (m *Model) saveDatabase() tea.Cmd {
+	if m.saving {
+		return nil
+	}
+	if m.options.Save == nil || m.options.SavePath == "" {
+		m.status = "Saving is disabled; no save file was given"
+		return nil
+	}
+	if m.cancel != nil {
+		m.status = "Query running; press Esc to cancel it before saving"
+		return nil
+	}
+	m.saving = true
+	m.status = "Saving database..."
+
+	save, path := m.options.Save, m.options.SavePath
+	return func() tea.Msg {
+		return saveDoneMsg{path: path, err: save(path)}
+	}
+}
+
+// handleSaveDone shows the outcome of a save.
+func (m *Model) handleSaveDone(msg saveDoneMsg) {
+	m.saving = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = ""
+		return
+	}
+	m.err = nil
+	m.status = fmt.Sprintf("Saved database to %s", msg.path)
+}
+
 // toggleMode switches between Query and Table modes.
 func (m *Model) toggleMode() tea.Cmd {
 	if m.mode == ModeQuery {
@@ -295,7 +390,7 @@ func (m *Model) calculateColumnWidth(numCols int) int {
 }
 
 // Run starts the UI application and returns the final query if any.
-func Run(db *sql.DB, tableNames []string) (*Result, error) {
+func Run(db *sql.DB, tableNames []string, options *Options) (*Result, error) {
 	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open /dev/tty: %w", err)
@@ -307,7 +402,7 @@ func Run(db *sql.DB, tableNames []string) (*Result, error) {
 	initStyles()
 
 	p := tea.NewProgram(
-		NewModel(db, tableNames),
+		NewModel(db, tableNames, options),
 		tea.WithAltScreen(),
 		tea.WithInput(tty),
 		tea.WithOutput(tty),
@@ -1,9 +1,14 @@
 package ui_test
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -25,9 +30,37 @@ func setupTestTable(t *testing.T) *sql.DB {
 	return db
 }
 
+// runQuery triggers execution of the pending query and feeds the result back
+// into the model, as the bubbletea runtime would.
+func runQuery(t *testing.T, m tea.Model, query string) tea.Model {
+	t.Helper()
+	updated, cmd := m.Update(ui.NewDebounceMsg(query))
+	return runCmd(updated, cmd)
+}
+
+// runCmd executes a command and its batched commands, updating the model with
+// the messages they produce.
+func runCmd(m tea.Model, cmd tea.Cmd) tea.Model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case nil:
+		return m
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			m = runCmd(m, cmd)
+		}
+		return m
+	default:
+		m, cmd = m.Update(msg)
+		return runCmd(m, cmd)
+	}
+}
+
 func TestNewModel(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 	view := m.View()
 
 	if !strings.Contains(view, "QUERY") {
@@ -54,7 +87,7 @@ func TestModel_Quit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := ui.NewModel(db, []string{"test"})
+			m := ui.NewModel(db, []string{"test"}, nil)
 			_, cmd := m.Update(tea.KeyMsg{Type: tt.keyType})
 			if cmd == nil {
 				t.Errorf("expected quit command for %s", tt.name)
@@ -65,7 +98,7 @@ func TestModel_Quit(t *testing.T) {
 
 func TestModel_Init(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 	cmd := m.Init()
 
 	if cmd == nil {
@@ -75,8 +108,8 @@ func TestModel_Init(t *testing.T) {
 
 func TestModel_WindowResize(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
-	updated, _ := m.Update(ui.NewDebounceMsg(m.PendingQuery()))
+	m := ui.NewModel(db, []string{"test"}, nil)
+	updated := runQuery(t, m, m.PendingQuery())
 
 	sizes := []tea.WindowSizeMsg{
 		{Width: 120, Height: 40},
@@ -92,11 +125,11 @@ func TestModel_WindowResize(t *testing.T) {
 
 func TestModel_ErrorDisplay(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("INVALID SQL")})
 	model := updated.(ui.Model)
-	updated, _ = updated.Update(ui.NewDebounceMsg(model.PendingQuery()))
+	updated = runQuery(t, updated, model.PendingQuery())
 
 	view := updated.View()
 	if !strings.Contains(view, "Error") {
@@ -118,7 +151,7 @@ func TestModel_EnterReturnsResult(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := ui.NewModel(db, []string{"test"})
+			m := ui.NewModel(db, []string{"test"}, nil)
 			var updated tea.Model = m
 
 			if tt.input != "" {
@@ -144,7 +177,7 @@ func TestModel_EnterReturnsResult(t *testing.T) {
 
 func TestModel_TableList(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"users", "orders"})
+	m := ui.NewModel(db, []string{"users", "orders"}, nil)
 	view := m.View()
 
 	if !strings.Contains(view, "Tables:") {
@@ -157,11 +190,11 @@ func TestModel_TableList(t *testing.T) {
 
 func TestModel_CellDetail(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 
 	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
 	model := updated.(ui.Model)
-	updated, _ = updated.Update(ui.NewDebounceMsg(model.PendingQuery()))
+	updated = runQuery(t, updated, model.PendingQuery())
 	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
 	view := updated.View()
 
@@ -178,11 +211,11 @@ func TestModel_CellDetail(t *testing.T) {
 
 func TestModel_TableNavigation(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 
 	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
 	model := updated.(ui.Model)
-	updated, _ = updated.Update(ui.NewDebounceMsg(model.PendingQuery()))
+	updated = runQuery(t, updated, model.PendingQuery())
 	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
 
 	view := updated.View()
@@ -211,7 +244,7 @@ func TestModel_TableNavigation(t *testing.T) {
 
 func TestModel_ToggleMode(t *testing.T) {
 	db := setupTestTable(t)
-	m := ui.NewModel(db, []string{"test"})
+	m := ui.NewModel(db, []string{"test"}, nil)
 
 	view := m.View()
 	if !strings.Contains(view, "QUERY") {
@@ -237,3 +270,287 @@ func TestModel_ToggleMode(t *testing.T) {
 		t.Error("expected QUERY mode after second Tab")
 	}
 }
+
+func TestModel_SaveDatabase(t *testing.T) {
+	db := setupTestTable(t)
+
+	var savedPath string
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		SavePath: "out.sqlite",
+		Save: func(path string) error {
+			savedPath = path
+			return nil
+		},
+	})
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if savedPath != "" {
+		t.Error("expected the save to run in a command, not in Update")
+	}
+	if !strings.Contains(updated.View(), "Saving database") {
+		t.Error("expected saving status in view")
+	}
+	updated = runCmd(updated, cmd)
+	if savedPath != "out.sqlite" {
+		t.Errorf("expected save to out.sqlite, got %q", savedPath)
+	}
+	if !strings.Contains(updated.View(), "Saved database to out.sqlite") {
+		t.Error("expected save status in view")
+	}
+}
+
+func TestModel_SaveDatabase_Error(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		SavePath: "out.sqlite",
+		Save: func(string) error {
+			return errors.New("disk full")
+		},
+	})
+
+	updated := runCmd(m.Update(tea.KeyMsg{Type: tea.KeyCtrlS}))
+	if !strings.Contains(updated.View(), "Error: disk full") {
+		t.Error("expected save error in view")
+	}
+}
+
+func TestModel_SaveDatabase_NoPath(t *testing.T) {
+	db := setupTestTable(t)
+	saved := false
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		Save: func(string) error {
+			saved = true
+			return nil
+		},
+	})
+
+	updated := runCmd(m.Update(tea.KeyMsg{Type: tea.KeyCtrlS}))
+	if saved {
+		t.Error("expected no save without a save path")
+	}
+	if !strings.Contains(updated.View(), "Saving is disabled") {
+		t.Error("expected disabled save status in view")
+	}
+}
+
+func TestModel_SaveDatabase_WhileQueryRuns(t *testing.T) {
+	db := setupTestTable(t)
+
+	saved := false
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		SavePath: "out.sqlite",
+		Save: func(string) error {
+			saved = true
+			return nil
+		},
+	})
+	updated, run := m.Update(ui.NewDebounceMsg(m.PendingQuery()))
+
+	updated = runCmd(updated.Update(tea.KeyMsg{Type: tea.KeyCtrlS}))
+	if saved {
+		t.Error("expected the save to be refused while a query runs")
+	}
+	if !strings.Contains(updated.View(), "Query running") {
+		t.Error("expected a hint to cancel the query in view")
+	}
+
+	updated = runCmd(updated, run)
+	updated = runCmd(updated.Update(tea.KeyMsg{Type: tea.KeyCtrlS}))
+	if !saved {
+		t.Error("expected the save to run once the query finished")
+	}
+}
+
+func TestModel_PrepareBeforeQuery(t *testing.T) {
+	db := setupTestTable(t)
+
+	var prepared []string
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		Prepare: func(_ context.Context, query string) (string, error) {
+			prepared = append(prepared, query)
+			return "SELECT 'rewritten' AS marker", nil
+		},
+	})
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	updated = runQuery(t, updated, m.PendingQuery())
+	if len(prepared) != 1 || prepared[0] != m.PendingQuery() {
+		t.Errorf("expected Prepare to be called with %q, got %v", m.PendingQuery(), prepared)
+	}
+	if !strings.Contains(updated.View(), "rewritten") {
+		t.Error("expected the prepared query to be executed")
+	}
+}
+
+func TestModel_PrepareError(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		Prepare: func(context.Context, string) (string, error) {
+			return "", errors.New("bad column list")
+		},
+	})
+
+	updated := runQuery(t, m, m.PendingQuery())
+	if !strings.Contains(updated.View(), "Error: bad column list") {
+		t.Error("expected Prepare error in view")
+	}
+}
+
+func TestModel_PrepareCanceled(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{
+		Prepare: func(ctx context.Context, query string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	})
+
+	updated, run := m.Update(ui.NewDebounceMsg(m.PendingQuery()))
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated = runCmd(updated, run)
+	if !strings.Contains(updated.View(), "Query canceled") {
+		t.Error("expected Esc to interrupt Prepare")
+	}
+}
+
+const runawayQuery = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+func TestModel_QueryTimeout(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{Timeout: 50 * time.Millisecond})
+
+	updated := runQuery(t, typeQuery(m, runawayQuery), runawayQuery)
+	if !strings.Contains(updated.View(), "query timed out after 50ms") {
+		t.Error("expected timeout error in view")
+	}
+}
+
+func TestModel_CancelQuery(t *testing.T) {
+	db := setupTestTable(t)
+
+	tests := []struct {
+		name    string
+		keyType tea.KeyType
+	}{
+		{"ctrl+c", tea.KeyCtrlC},
+		{"esc", tea.KeyEsc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ui.NewModel(db, []string{"test"}, nil)
+			updated, run := m.Update(ui.NewDebounceMsg(m.PendingQuery()))
+			if !strings.Contains(updated.View(), "Running query") {
+				t.Error("expected running status in view")
+			}
+
+			updated, cmd := updated.Update(tea.KeyMsg{Type: tt.keyType})
+			if cmd != nil {
+				if _, quit := cmd().(tea.QuitMsg); quit {
+					t.Fatal("expected the query to be canceled instead of quitting")
+				}
+			}
+
+			updated = runCmd(updated, run)
+			if !strings.Contains(updated.View(), "Query canceled") {
+				t.Error("expected canceled status in view")
+			}
+
+			// Once idle, the key quits again
+			_, cmd = updated.Update(tea.KeyMsg{Type: tt.keyType})
+			if cmd == nil {
+				t.Error("expected quit command when no query is running")
+			}
+		})
+	}
+}
+
+// typeQuery replaces the default query in the input with query.
+func typeQuery(m tea.Model, query string) tea.Model {
+	for range "SELECT * FROM test LIMIT 10" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(query)})
+	return m
+}
+
+func countRows(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM test").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return n
+}
+
+func TestModel_WriteRequiresConfirmation(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, nil)
+	query := "DELETE FROM test"
+
+	updated := runQuery(t, typeQuery(m, query), query)
+	if n := countRows(t, db); n != 2 {
+		t.Fatalf("expected the write to be held back, got %d rows", n)
+	}
+	if !strings.Contains(updated.View(), "Ctrl+R") {
+		t.Error("expected confirmation hint in view")
+	}
+
+	// The guard does not outlive the query
+	if _, err := db.Exec("INSERT INTO test VALUES (3, 'Carol')"); err != nil {
+		t.Fatalf("expected writes outside the guard to succeed: %v", err)
+	}
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	updated = runCmd(updated, cmd)
+	if n := countRows(t, db); n != 0 {
+		t.Errorf("expected the confirmed write to run, got %d rows", n)
+	}
+	if !strings.Contains(updated.View(), "Statement executed") {
+		t.Error("expected executed status in view")
+	}
+}
+
+func TestModel_ReadOnly(t *testing.T) {
+	db := setupTestTable(t)
+	m := ui.NewModel(db, []string{"test"}, &ui.Options{ReadOnly: true})
+	query := "DROP TABLE test"
+
+	updated := runQuery(t, typeQuery(m, query), query)
+	if !strings.Contains(updated.View(), "read-only mode") {
+		t.Error("expected read-only error in view")
+	}
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	runCmd(updated, cmd)
+	if n := countRows(t, db); n != 2 {
+		t.Errorf("expected the table to be untouched, got %d rows", n)
+	}
+}
+
+func TestModel_ReadOnly_QueryOnlyBypass(t *testing.T) {
+	dir := t.TempDir()
+	queries := []string{
+		"PRAGMA query_only = OFF; DELETE FROM test; SELECT count(*) FROM test",
+		"ATTACH '" + filepath.Join(dir, "a.db") + "' AS a",
+		"VACUUM INTO '" + filepath.Join(dir, "b.db") + "'",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			db := setupTestTable(t)
+			m := ui.NewModel(db, []string{"test"}, &ui.Options{ReadOnly: true})
+
+			updated := runQuery(t, typeQuery(m, query), query)
+			if !strings.Contains(updated.View(), "read-only mode") {
+				t.Error("expected read-only error in view")
+			}
+			if n := countRows(t, db); n != 2 {
+				t.Errorf("expected the table to be untouched, got %d rows", n)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("expected no files to be created, got %v", entries)
+			}
+		})
+	}
+}
@@ -79,9 +79,12 @@ func (m Model) renderCellDetail() string {
 	return styleTextBase.Render(fmt.Sprintf("\n%s%s", prefix, truncatedValue))
 }
 
-// renderError returns the error view. Always returns a line to prevent layout shift.
+// renderError returns the error or status view. Always returns a line to prevent layout shift.
 func (m Model) renderError() string {
 	if m.err == nil {
+		if m.status != "" {
+			return styleTextMuted.Render(fmt.Sprintf("\n%s", m.status))
+		}
 		return "\n"
 	}
 	return styleTextError.Render(fmt.Sprintf("\nError: %v", m.err))